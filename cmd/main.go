@@ -1,10 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
 	"project/controllers"
 	_ "project/docs"
 	"project/middlewares"
+	"project/models"
 	"project/services"
+	"project/utils"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -42,56 +51,179 @@ import (
 
 // @tag.name categories
 // @tag.description Управление категориями
+
+// @tag.name coupons
+// @tag.description Управление купонами на скидку
+
+// @tag.name health
+// @tag.description Проверка состояния сервиса
+
+// @tag.name reports
+// @tag.description Отчеты для администраторов
+
+// @tag.name audit
+// @tag.description Журнал действий администраторов
 func main() {
 	services.InitDB()
-	router := gin.Default()
+	services.InitNotifier()
+	models.ClockSkew = services.AppConfig.JWTClockSkew
+	utils.BcryptCost = services.AppConfig.BcryptCost
+	utils.DefaultPageLimits = utils.PageLimits{
+		Default: services.AppConfig.DefaultPageLimit,
+		Max:     services.AppConfig.MaxPageLimit,
+	}
+	pageLimitOverrides, err := utils.ParsePageLimitOverrides(services.AppConfig.PageLimitOverrides)
+	if err != nil {
+		log.Fatal("Invalid PAGE_LIMIT_OVERRIDES:", err)
+	}
+	utils.PageLimitOverrides = pageLimitOverrides
+	utils.PasswordRules = utils.PasswordPolicy{
+		MinLength:         services.AppConfig.PasswordMinLength,
+		RequireMixedChars: services.AppConfig.PasswordRequireMixed,
+	}
+
+	router := gin.New()
+	router.Use(middlewares.RequestIDMiddleware(), middlewares.LoggerMiddleware(), gin.Recovery(), middlewares.GzipMiddleware(services.AppConfig.GzipMinSize))
+	router.Use(middlewares.TimeoutMiddleware(services.AppConfig.RequestTimeout, map[string]time.Duration{
+		"/products/export": services.AppConfig.ExportTimeout,
+	}))
+	router.Use(middlewares.BodyLimitMiddleware(services.AppConfig.MaxBodyBytes, map[string]int64{
+		"/products/bulk":             services.AppConfig.MaxBulkBodyBytes,
+		"/orders/:id/products/batch": services.AppConfig.MaxBulkBodyBytes,
+	}))
 
 	router.GET("/swagger/*any", gin.WrapF(httpSwagger.WrapHandler))
 
-	router.POST("/login", controllers.Login)
+	router.GET("/healthz", controllers.Healthz)
+	router.GET("/readyz", controllers.Readyz)
+
+	router.POST("/login", middlewares.LoginRateLimitMiddleware(services.AppConfig.LoginRateLimit, services.AppConfig.LoginRateWindow), controllers.Login)
 	router.POST("/register", controllers.Register)
 	router.POST("/refresh", controllers.Refresh)
+	router.POST("/logout", controllers.Logout)
+	router.GET("/password-policy", controllers.GetPasswordPolicy)
+
+	// Отзывы о продукте доступны без авторизации, оставлять их могут только авторизованные пользователи
+	router.GET("/products/:id/reviews", middlewares.OptionalAuthMiddleware(), controllers.GetProductReviews)
+	router.GET("/products/:id/reviews/:review_id", middlewares.OptionalAuthMiddleware(), controllers.GetReviewByID)
 
 	protected := router.Group("/")
-	protected.Use(middlewares.AuthMiddleware())
+	protected.Use(middlewares.AuthMiddleware(), middlewares.ActiveUserMiddleware())
 	{
 		protected.GET("/products/count-by-manufacturer", controllers.CountProductsByManufacturer)
+		protected.GET("/products/stats-by-manufacturer", controllers.GetManufacturerStats)
 		protected.GET("/products/price-range", controllers.GetProductsByPriceRange)
+		protected.GET("/products/manufacturers", controllers.GetManufacturers)
 		protected.PUT("/products/manufacturer", middlewares.RoleMiddleware("admin"), controllers.UpdateProductsManufacturer)
+		protected.PUT("/products/category", middlewares.RoleMiddleware("admin"), controllers.UpdateProductsCategory)
+
+		protected.GET("/products/export", middlewares.RoleMiddleware("admin"), controllers.ExportProductsCSV)
 
 		protected.GET("/products", controllers.GetProductsWithTimeout)
 		protected.GET("/products/:id", controllers.GetProductByID)
+		protected.GET("/products/:id/related", controllers.GetRelatedProducts)
+		protected.GET("/products/:id/variants", controllers.GetProductVariants)
+		protected.POST("/products/:id/variants", middlewares.RoleMiddleware("admin"), controllers.CreateProductVariant)
+		protected.PUT("/products/:id/variants/:variant_id", middlewares.RoleMiddleware("admin"), controllers.UpdateProductVariant)
+		protected.DELETE("/products/:id/variants/:variant_id", middlewares.RoleMiddleware("admin"), controllers.DeleteProductVariant)
 		protected.POST("/products", middlewares.RoleMiddleware("admin"), controllers.CreateProduct)
+		protected.POST("/products/bulk", middlewares.RoleMiddleware("admin"), controllers.CreateProductsBulk)
 		protected.PUT("/products/:id", middlewares.RoleMiddleware("admin"), controllers.UpdateProduct)
+		protected.PATCH("/products/:id", middlewares.RoleMiddleware("admin"), controllers.PatchProduct)
 		protected.DELETE("/products/:id", middlewares.RoleMiddleware("admin"), controllers.DeleteProduct)
-		protected.POST("/products/:id/reviews", controllers.CreateReview)
-		router.GET("/products/:id/reviews", controllers.GetProductReviews)
+		protected.DELETE("/products", middlewares.RoleMiddleware("admin"), controllers.DeleteProducts)
+		protected.POST("/products/:id/reviews", middlewares.UserRateLimitMiddleware(services.AppConfig.ReviewRateLimit, services.AppConfig.ReviewRateWindow, "too many reviews, try again later"), controllers.CreateReview)
 
 		protected.GET("/categories", controllers.GetCategoriesWithTimeout)
 		protected.GET("/categories/:id", controllers.GetCategoryByID)
+		protected.GET("/categories/:id/products", controllers.GetCategoryProducts)
 		protected.POST("/categories", middlewares.RoleMiddleware("admin"), controllers.CreateCategory)
 		protected.PUT("/categories/:id", middlewares.RoleMiddleware("admin"), controllers.UpdateCategory)
+		protected.PATCH("/categories/:id", middlewares.RoleMiddleware("admin"), controllers.PatchCategory)
 		protected.DELETE("/categories/:id", middlewares.RoleMiddleware("admin"), controllers.DeleteCategory)
 
 		protected.GET("/orders", controllers.GetUserOrders)
 		protected.GET("/orders/:id", controllers.GetOrderByID)
+		protected.GET("/orders/:id/summary", controllers.GetOrderSummary)
 		protected.POST("orders/:id/products", controllers.AddProductToOrder)
+		protected.POST("orders/:id/products/batch", controllers.AddProductsToOrderBatch)
 		protected.POST("/orders", controllers.CreateOrder)
 		protected.PATCH("orders/:id/products/:product_id", controllers.UpdateProductQuantity)
+		protected.PATCH("/orders/:id/products", controllers.UpdateOrderProductsBatch)
 		protected.DELETE("/orders/:id/products/:product_id", controllers.DeleteProductFromOrder)
+		protected.DELETE("/orders/:id/products", controllers.ClearOrderProducts)
 		protected.DELETE("/orders/:id", controllers.DeleteOrder)
 		protected.GET("/admin/orders", middlewares.RoleMiddleware("admin"), controllers.GetAllOrders)
 		protected.DELETE("/admin/orders/:id", middlewares.RoleMiddleware("admin"), controllers.DeleteOrderAdmin)
+		protected.GET("/admin/reports/sales", middlewares.RoleMiddleware("admin"), controllers.GetSalesReport)
+		protected.POST("/admin/users", middlewares.RoleMiddleware("admin"), controllers.CreateUserAdmin)
+		protected.GET("/admin/users/:id/orders", middlewares.RoleMiddleware("admin"), controllers.GetUserOrdersAdmin)
+		protected.GET("/admin/products/low-stock", middlewares.RoleMiddleware("admin"), controllers.GetLowStockProducts)
+		protected.GET("/admin/products/orphaned", middlewares.RoleMiddleware("admin"), controllers.GetOrphanedProducts)
+		protected.POST("/admin/products/orphaned/repair", middlewares.RoleMiddleware("admin"), controllers.RepairOrphanedProducts)
+		protected.POST("/admin/products/:id/merge", middlewares.RoleMiddleware("admin"), controllers.MergeProduct)
+		protected.GET("/admin/reviews", middlewares.RoleMiddleware("admin"), controllers.GetAllReviews)
+		protected.DELETE("/admin/reviews/:id", middlewares.RoleMiddleware("admin"), controllers.DeleteReviewAdmin)
+		protected.GET("/admin/audit-log", middlewares.RoleMiddleware("admin"), controllers.GetAuditLog)
+		protected.POST("/orders/:id/apply-coupon", controllers.ApplyCoupon)
+		protected.POST("/orders/:id/checkout", controllers.CheckoutOrder)
+		protected.POST("/orders/:id/cancel", controllers.CancelOrder)
+		protected.POST("/orders/:id/reorder", controllers.ReorderOrder)
+
+		protected.GET("/admin/coupons", middlewares.RoleMiddleware("admin"), controllers.GetCoupons)
+		protected.GET("/admin/coupons/:id", middlewares.RoleMiddleware("admin"), controllers.GetCouponByID)
+		protected.POST("/admin/coupons", middlewares.RoleMiddleware("admin"), controllers.CreateCoupon)
+		protected.PUT("/admin/coupons/:id", middlewares.RoleMiddleware("admin"), controllers.UpdateCoupon)
+		protected.DELETE("/admin/coupons/:id", middlewares.RoleMiddleware("admin"), controllers.DeleteCoupon)
 
 		protected.GET("users/me", controllers.GetUserInfo)
 		protected.DELETE("users/me", controllers.DeleteSelf)
 		protected.PATCH("users/me/username", controllers.UpdateUserName)
+		protected.PATCH("users/me/email", controllers.UpdateUserEmail)
 		protected.PATCH("users/me/password", controllers.UpdateUserPassword)
+		protected.GET("users/me/reviews", controllers.GetMyReviews)
+		protected.GET("users/me/export", controllers.ExportMyData)
+		protected.GET("users/me/stats", controllers.GetMyStats)
+		protected.GET("users/me/reviewable", controllers.GetMyReviewableProducts)
 		protected.PATCH("/users/:id/role", middlewares.RoleMiddleware("admin"), controllers.UpdateUserRole)
 		protected.DELETE("/users/:id", middlewares.RoleMiddleware("admin"), controllers.DeleteUser)
 		protected.GET("/users", middlewares.RoleMiddleware("admin"), controllers.GetAllUsers)
 		protected.GET("/users/:id", middlewares.RoleMiddleware("admin"), controllers.GetUserByID)
 	}
 
-	router.Run(":8080")
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	services.StartReservationSweeper(ctx, services.AppConfig.ReservationSweepInterval)
+
+	go func() {
+		log.Println("Server listening on", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutdown signal received, stopping server...")
+
+	// Даем текущим запросам завершиться, прежде чем закрыть соединения с БД
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shut down:", err)
+	} else {
+		log.Println("Server stopped gracefully")
+	}
+
+	if err := services.CloseDB(); err != nil {
+		log.Println("Error closing database:", err)
+	} else {
+		log.Println("Database connections closed")
+	}
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+	"os"
+	"project/services"
+	"project/utils"
+)
+
+// Заполняет базу данных демонстрационными данными: go run ./cmd/seed
+// Пароли пользователей admin и demo задаются через SEED_ADMIN_PASSWORD и SEED_USER_PASSWORD.
+func main() {
+	services.InitDB()
+	defer services.CloseDB()
+
+	utils.BcryptCost = services.AppConfig.BcryptCost
+
+	adminPassword := getEnv("SEED_ADMIN_PASSWORD", "admin123")
+	userPassword := getEnv("SEED_USER_PASSWORD", "demo123")
+
+	if err := services.Seed(adminPassword, userPassword); err != nil {
+		log.Fatalf("Seed failed: %v", err)
+	}
+	log.Println("Seed completed")
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
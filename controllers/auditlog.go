@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"net/http"
+	"project/models"
+	"project/services"
+	"project/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordAudit записывает действие текущего администратора в журнал аудита
+func recordAudit(c *gin.Context, action, target string, details models.StringMap) {
+	actorID, _ := c.Get("user_id")
+	id, _ := actorID.(int)
+	services.RecordAudit(id, action, target, details)
+}
+
+// joinIDs записывает список ID для деталей записи аудита: "1,2,3"
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// GetAuditLog godoc
+// @Summary Журнал действий администраторов
+// @Description Возвращает записи журнала аудита, начиная с новых, с пагинацией и фильтрами по администратору и действию
+// @Tags audit
+// @Produce json
+// @Param Authorization header string false "Токен доступа пользователя (JWT)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Param actor_id query int false "ID администратора"
+// @Param action query string false "Действие, например user.role_update"
+// @Success 200 {object} models.AuditLogResponse "Записи журнала"
+// @Failure 400 {object} models.ErrorResponse "Некорректные параметры запроса"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/audit-log [get]
+func GetAuditLog(c *gin.Context) {
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	query := requestDB(c).Model(&models.AuditLog{})
+
+	if raw := c.Query("actor_id"); raw != "" {
+		actorID, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Invalid actor_id")
+			return
+		}
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching audit log")
+		return
+	}
+
+	entries := []models.AuditLog{}
+	if err := query.
+		Order("created_at desc, id desc").
+		Limit(limitInt).
+		Offset((pageInt - 1) * limitInt).
+		Find(&entries).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuditLogResponse{
+		Data:       entries,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
+	})
+}
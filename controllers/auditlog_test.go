@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"project/models"
+	"project/services"
+	"testing"
+)
+
+func assertAudit(t *testing.T, actor models.User, action, target string) models.AuditLog {
+	t.Helper()
+	var entries []models.AuditLog
+	if err := services.DB.Where("action = ?", action).Find(&entries).Error; err != nil {
+		t.Fatalf("fetch audit log: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("%d audit entries for %s, want 1", len(entries), action)
+	}
+	if entries[0].ActorID != actor.ID || entries[0].Target != target {
+		t.Fatalf("audit entry = actor %d target %s, want actor %d target %s", entries[0].ActorID, entries[0].Target, actor.ID, target)
+	}
+	return entries[0]
+}
+
+func TestAuditProductActions(t *testing.T) {
+	t.Run("bulk create", func(t *testing.T) {
+		setupDB(t)
+		admin := createUser(t, models.RoleAdmin)
+		product := map[string]interface{}{
+			"name":        "Whey",
+			"price":       10,
+			"stock":       1,
+			"currency":    services.AppConfig.BaseCurrency,
+			"category_id": services.UncategorizedCategoryID,
+		}
+		recorder := perform(t, CreateProductsBulk, testRequest{
+			method: http.MethodPost, route: "/products/bulk", target: "/products/bulk", user: &admin,
+			body: []map[string]interface{}{product, product},
+		})
+		assertStatus(t, recorder, http.StatusCreated)
+		entry := assertAudit(t, admin, models.AuditActionProductsCreate, "products:bulk")
+		if entry.Details["created"] != "2" || entry.Details["ids"] != "1,2" {
+			t.Fatalf("details = %v, want two created products", entry.Details)
+		}
+	})
+
+	t.Run("create", func(t *testing.T) {
+		setupDB(t)
+		admin := createUser(t, models.RoleAdmin)
+		recorder := perform(t, CreateProduct, testRequest{
+			method: http.MethodPost, route: "/products", target: "/products", user: &admin,
+			body: models.ProductRequest{
+				Name: "Whey", Price: 10, Stock: 1, Currency: services.AppConfig.BaseCurrency, CategoryID: services.UncategorizedCategoryID,
+			},
+		})
+		assertStatus(t, recorder, http.StatusCreated)
+		var product models.Product
+		decodeBody(t, recorder, &product)
+		entry := assertAudit(t, admin, models.AuditActionProductCreate, fmt.Sprintf("product:%d", product.ID))
+		if entry.Details["name"] != "Whey" {
+			t.Fatalf("details = %v, want the product name", entry.Details)
+		}
+	})
+
+	t.Run("bulk delete", func(t *testing.T) {
+		setupDB(t)
+		admin := createUser(t, models.RoleAdmin)
+		first := createProduct(t, 10, 1)
+		second := createProduct(t, 20, 1)
+		recorder := perform(t, DeleteProducts, testRequest{
+			method: http.MethodDelete, route: "/products", target: "/products", user: &admin,
+			body: models.DeleteProductsRequest{IDs: []int{first.ID, second.ID, 999}},
+		})
+		assertStatus(t, recorder, http.StatusOK)
+		entry := assertAudit(t, admin, models.AuditActionProductsDelete, "products:bulk")
+		if entry.Details["deleted"] != "2" {
+			t.Fatalf("details = %v, want two deleted products", entry.Details)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		setupDB(t)
+		admin := createUser(t, models.RoleAdmin)
+		product := createProduct(t, 10, 1)
+		recorder := perform(t, DeleteProduct, testRequest{
+			method: http.MethodDelete, route: "/products/:id", target: fmt.Sprintf("/products/%d", product.ID), user: &admin,
+		})
+		assertStatus(t, recorder, http.StatusOK)
+		assertAudit(t, admin, models.AuditActionProductDelete, fmt.Sprintf("product:%d", product.ID))
+	})
+
+	t.Run("failed delete is not recorded", func(t *testing.T) {
+		setupDB(t)
+		admin := createUser(t, models.RoleAdmin)
+		recorder := perform(t, DeleteProduct, testRequest{
+			method: http.MethodDelete, route: "/products/:id", target: "/products/999", user: &admin,
+		})
+		assertStatus(t, recorder, http.StatusNotFound)
+		var count int64
+		services.DB.Model(&models.AuditLog{}).Count(&count)
+		if count != 0 {
+			t.Fatalf("%d audit entries after a failed delete, want 0", count)
+		}
+	})
+}
+
+func TestAuditUserCreate(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	recorder := perform(t, CreateUserAdmin, testRequest{
+		method: http.MethodPost, route: "/admin/users", target: "/admin/users", user: &admin,
+		body: models.CreateUserRequest{Username: "manager", Password: "secret123", Email: "manager@example.com", Role: models.RoleAdmin},
+	})
+	assertStatus(t, recorder, http.StatusCreated)
+	var created models.UserProfile
+	decodeBody(t, recorder, &created)
+	entry := assertAudit(t, admin, models.AuditActionUserCreate, fmt.Sprintf("user:%d", created.ID))
+	if entry.Details["role"] != models.RoleAdmin {
+		t.Fatalf("details = %v, want role %s", entry.Details, models.RoleAdmin)
+	}
+}
+
+func TestAuditCouponActions(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+
+	recorder := perform(t, CreateCoupon, testRequest{
+		method: http.MethodPost, route: "/admin/coupons", target: "/admin/coupons", user: &admin,
+		body: models.Coupon{Code: "sale", Type: models.CouponTypePercent, Value: 10},
+	})
+	assertStatus(t, recorder, http.StatusCreated)
+	var coupon models.Coupon
+	decodeBody(t, recorder, &coupon)
+	target := fmt.Sprintf("coupon:%d", coupon.ID)
+	assertAudit(t, admin, models.AuditActionCouponCreate, target)
+
+	recorder = perform(t, UpdateCoupon, testRequest{
+		method: http.MethodPut, route: "/admin/coupons/:id", target: fmt.Sprintf("/admin/coupons/%d", coupon.ID), user: &admin,
+		body: models.Coupon{Code: "sale", Type: models.CouponTypeFixed, Value: 5},
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	assertAudit(t, admin, models.AuditActionCouponUpdate, target)
+
+	recorder = perform(t, DeleteCoupon, testRequest{
+		method: http.MethodDelete, route: "/admin/coupons/:id", target: fmt.Sprintf("/admin/coupons/%d", coupon.ID), user: &admin,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	assertAudit(t, admin, models.AuditActionCouponDelete, target)
+}
+
+func TestAuditReviewDelete(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	author := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 1)
+	createReview(t, author, product, 2)
+	var review models.Review
+	services.DB.Where("product_id = ?", product.ID).First(&review)
+
+	recorder := perform(t, DeleteReviewAdmin, testRequest{
+		method: http.MethodDelete, route: "/admin/reviews/:id", target: fmt.Sprintf("/admin/reviews/%d", review.ID), user: &admin,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	entry := assertAudit(t, admin, models.AuditActionReviewDelete, fmt.Sprintf("review:%d", review.ID))
+	if entry.Details["user_id"] != fmt.Sprint(author.ID) || entry.Details["rating"] != "2" {
+		t.Fatalf("details = %v, want the deleted review's author and rating", entry.Details)
+	}
+}
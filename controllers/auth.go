@@ -1,110 +1,160 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"project/models"
 	"project/services"
 	"project/utils"
-	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Login godoc
 // @Summary      Авторизация пользователя
-// @Description  Эндпоинт для авторизации пользователя. При успешной авторизации возвращает JWT-токен.
+// @Description  Эндпоинт для авторизации пользователя. При успешной авторизации возвращает короткоживущий JWT-токен доступа и долгоживущий токен обновления.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        credentials body models.Credentials true "Учетные данные пользователя"
-// @Success      200 {object} models.TokenResponse "Возвращает jwt-токен"
+// @Success      200 {object} models.TokenResponse "Возвращает jwt-токен и токен обновления"
 // @Failure      400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure      401 {object} models.ErrorResponse "Некорректное имя пользователя"
 // @Failure      401 {object} models.ErrorResponse "Некорректный пароль"
+// @Failure      403 {object} models.ErrorResponse "Учетная запись деактивирована"
+// @Failure      429 {object} models.ErrorResponse "Слишком много попыток входа"
 // @Failure      500 {object} models.ErrorResponse "Невозможно создать токен"
 // @Router       /login [post]
 func Login(c *gin.Context) {
 	var creds models.Credentials
-	if err := c.BindJSON(&creds); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "invalid request")
+	if err := utils.BindAndValidate(c, &creds); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Ищем пользователя
 	var user models.User
-	if err := services.DB.Where("username = ?", creds.Username).First(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusUnauthorized, "invalid username")
+	if err := requestDB(c).Where("LOWER(username) = ?", utils.NormalizeUsername(creds.Username)).First(&user).Error; err != nil {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeInvalidCredentials, "invalid username")
 		return
 	}
 
 	// Проверяем пароль
 	if !utils.CheckPassword(user.Password, creds.Password) {
-		utils.HandleError(c, http.StatusUnauthorized, "invalid password")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeInvalidCredentials, "invalid password")
 		return
 	}
 
+	if !user.Active {
+		utils.HandleError(c, http.StatusForbidden, models.ErrCodeAccountDeactivated, "account is deactivated")
+		return
+	}
+
+	// Пересчитываем хеш, созданный с устаревшей стоимостью; ошибка не мешает входу
+	if utils.NeedsRehash(user.Password) {
+		if hashedPassword, err := utils.HashPassword(creds.Password); err != nil {
+			log.Println("Error rehashing password:", err)
+		} else if err := requestDB(c).Model(&user).Update("password", hashedPassword).Error; err != nil {
+			log.Println("Error storing rehashed password:", err)
+		}
+	}
+
 	// Генерация токена с ролью пользователя
 	token, err := services.GenerateToken(int(user.ID), user.Username, user.Role)
 	if err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "could not create token")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "could not create token")
 		return
 	}
+
+	refreshToken, err := services.IssueRefreshToken(requestDB(c), user.ID)
+	if err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "could not create token")
+		return
+	}
+
 	c.JSON(http.StatusOK, models.TokenResponse{
-		Token: token,
+		Token:        token,
+		RefreshToken: refreshToken,
 	})
 }
 
 // Register godoc
 // @Summary      Регистрация пользователя
-// @Description  Эндпоинт для регистрации нового пользователя. Возвращает сообщение об успешной регистрации.
+// @Description  Эндпоинт для регистрации нового пользователя. Имя пользователя приводится к нижнему регистру и должно быть уникальным без учета регистра.
+// @Description  Зарегистрированный пользователь всегда получает роль user: поле role в запросе игнорируется, другие роли назначает администратор через PATCH /users/{id}/role.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        credentials body models.Credentials true "Учетные данные пользователя (username, password, optional: role)"
+// @Param        credentials body models.Credentials true "Учетные данные пользователя (username, password, email)"
 // @Success      201 {object} models.MessageResponse "Пользователь успешно зарегистрирован"
 // @Failure      400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure      409 {object} models.ErrorResponse "Пользователь уже существует"
+// @Failure      422 {object} models.ErrorResponse "Ошибки в полях формы, перечисленные в fields"
 // @Failure      500 {object} models.ErrorResponse "Невозможно зарегистрировать пользователя"
 // @Router       /register [post]
 func Register(c *gin.Context) {
 	var creds models.Credentials
-	if err := c.BindJSON(&creds); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "invalid request")
+	if err := utils.BindAndValidate(c, &creds); err != nil {
+		utils.HandleValidationError(c, err)
 		return
 	}
 
-	if len(creds.Username) < 2 {
-		utils.HandleError(c, http.StatusBadRequest, "Username length is less than 2")
-		return
+	// Проверяем все поля сразу, чтобы клиент получил полный список ошибок формы
+	var fieldErrs utils.FieldErrors
+
+	username := utils.NormalizeUsername(creds.Username)
+	if len(username) < 2 {
+		fieldErrs.Add("username", "Username length is less than 2")
+	}
+
+	if err := utils.ValidatePassword(creds.Password); err != nil {
+		fieldErrs.Add("password", err.Error())
 	}
 
-	if len(creds.Password) < 6 {
-		utils.HandleError(c, http.StatusBadRequest, "Password length is less than 6")
+	email := utils.NormalizeEmail(creds.Email)
+	if !utils.IsValidEmail(email) {
+		fieldErrs.Add("email", "Invalid email format")
+	}
+
+	if err := fieldErrs.Err(); err != nil {
+		utils.HandleValidationError(c, err)
 		return
 	}
 
 	var existingUser models.User
-	if err := services.DB.Where("username = ?", creds.Username).First(&existingUser).Error; err == nil {
-		utils.HandleError(c, http.StatusConflict, "user already exists")
+	if err := requestDB(c).Where("LOWER(username) = ?", username).First(&existingUser).Error; err == nil {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "user already exists")
+		return
+	}
+
+	if err := requestDB(c).Where("email = ?", email).First(&existingUser).Error; err == nil {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "email already taken")
 		return
 	}
 
 	hashedPassword, err := utils.HashPassword(creds.Password)
 	if err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "failed to register user")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "failed to register user")
 		return
 	}
 
-	// Регистрируем пользователя
+	// Регистрируем пользователя. Роль не берется из запроса: иначе любой мог бы зарегистрироваться администратором
 	newUser := models.User{
-		Username: creds.Username,
+		Username: username,
+		Email:    email,
 		Password: hashedPassword,
-		Role:     "user",
+		Role:     models.RoleUser,
 	}
 
-	if err := services.DB.Create(&newUser).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "failed to register user")
+	if err := requestDB(c).Create(&newUser).Error; err != nil {
+		// Параллельная регистрация с тем же именем или адресом успела раньше и сработал уникальный индекс
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "user already exists")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "failed to register user")
+		}
 		return
 	}
 	c.JSON(http.StatusCreated, models.MessageResponse{
@@ -112,46 +162,98 @@ func Register(c *gin.Context) {
 	})
 }
 
+// GetPasswordPolicy godoc
+// @Summary      Требования к паролю
+// @Description  Возвращает действующие правила сложности пароля, чтобы клиент мог проверить пароль до отправки.
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} models.PasswordPolicyResponse "Правила сложности пароля"
+// @Router       /password-policy [get]
+func GetPasswordPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, models.PasswordPolicyResponse{
+		MinLength:         utils.PasswordRules.MinLength,
+		RequireMixedChars: utils.PasswordRules.RequireMixedChars,
+	})
+}
+
 // Refresh godoc
 // @Summary      Обновление токена
-// @Description  Эндпоинт для обновления JWT токена. Генерирует новый токен, если исходный почти истек.
+// @Description  Обменивает действующий токен обновления на новый токен доступа. Предъявленный токен обновления отзывается и заменяется новым; повторное использование отозванного токена отзывает все сессии пользователя.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        Authorization header string true "токен"
-// @Success      200 {object} models.TokenResponse "новый JWT токен"
-// @Failure      400 {object} models.ErrorResponse "Токен еще не истек"
-// @Failure      401 {object} models.ErrorResponse "Пользователь не авторизирован"
+// @Param        request body models.RefreshTokenRequest true "Токен обновления"
+// @Success      200 {object} models.TokenResponse "Новый JWT-токен и токен обновления"
+// @Failure      400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure      401 {object} models.ErrorResponse "Токен обновления недействителен, истек или использован повторно"
+// @Failure      403 {object} models.ErrorResponse "Учетная запись деактивирована"
 // @Failure      500 {object} models.ErrorResponse "Невозможно создать токен"
 // @Router       /refresh [post]
 func Refresh(c *gin.Context) {
-	tokenString := c.GetHeader("Authorization")
-	claims := &models.Claims{}
-
-	// Парсим исходный токен
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return services.JwtKey, nil
-	})
-
-	if err != nil || !token.Valid {
-		utils.HandleError(c, http.StatusUnauthorized, "unauthorized")
-
+	var request models.RefreshTokenRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
-	// Проверяем, не истек ли срок действия токена
-	if time.Unix(claims.ExpiresAt, 0).Sub(time.Now()) > 120*time.Second {
-		utils.HandleError(c, http.StatusBadRequest, "token not expired enough")
+	user, refreshToken, err := services.RotateRefreshToken(request.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenExpired):
+			utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeTokenExpired, "refresh token expired")
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeInvalidToken, "refresh token reuse detected")
+		case errors.Is(err, services.ErrRefreshTokenInvalid):
+			utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeInvalidToken, "invalid refresh token")
+		case errors.Is(err, services.ErrAccountDeactivated):
+			utils.HandleError(c, http.StatusForbidden, models.ErrCodeAccountDeactivated, "account is deactivated")
+		default:
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "could not create token")
+		}
 		return
 	}
 
-	// Генерация нового токена с теми же данными (пользователь и роль), но с новым временем истечения
-	newToken, err := services.GenerateToken(claims.UserID, claims.Username, claims.Role)
+	// Роль берем из базы, чтобы изменения прав применялись при обновлении токена
+	token, err := services.GenerateToken(user.ID, user.Username, user.Role)
 	if err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "token not create token")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "could not create token")
 		return
 	}
 	c.JSON(http.StatusOK, models.TokenResponse{
-		Token: newToken,
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// Logout godoc
+// @Summary      Выход из системы
+// @Description  Отзывает токен обновления. Уже выданный токен доступа остается действительным до истечения срока.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.RefreshTokenRequest true "Токен обновления"
+// @Success      200 {object} models.MessageResponse "Токен отозван"
+// @Failure      400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure      401 {object} models.ErrorResponse "Токен обновления недействителен"
+// @Failure      500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router       /logout [post]
+func Logout(c *gin.Context) {
+	var request models.RefreshTokenRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	if err := services.RevokeRefreshToken(request.RefreshToken); err != nil {
+		if errors.Is(err, services.ErrRefreshTokenInvalid) {
+			utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeInvalidToken, "invalid refresh token")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "could not revoke token")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "logged out successfully",
 	})
 }
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/middlewares"
+	"project/models"
+	"project/services"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func loginTokens(t *testing.T, user models.User) models.TokenResponse {
+	t.Helper()
+	recorder := login(t, user)
+	assertStatus(t, recorder, http.StatusOK)
+	var tokens models.TokenResponse
+	decodeBody(t, recorder, &tokens)
+	return tokens
+}
+
+func refreshRequest(t *testing.T, handler gin.HandlerFunc, refreshToken string) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, handler, testRequest{
+		method: http.MethodPost, route: "/refresh", target: "/refresh",
+		body: models.RefreshTokenRequest{RefreshToken: refreshToken},
+	})
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	setPassword(t, &user)
+	first := loginTokens(t, user)
+
+	recorder := refreshRequest(t, Refresh, first.RefreshToken)
+	assertStatus(t, recorder, http.StatusOK)
+	var second models.TokenResponse
+	decodeBody(t, recorder, &second)
+	if second.Token == "" || second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh returned %+v, want a new token pair", second)
+	}
+
+	assertStatus(t, refreshRequest(t, Refresh, second.RefreshToken), http.StatusOK)
+}
+
+func TestRefreshReuseRevokesAllSessions(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	setPassword(t, &user)
+	stolen := loginTokens(t, user)
+	other := loginTokens(t, user)
+
+	recorder := refreshRequest(t, Refresh, stolen.RefreshToken)
+	assertStatus(t, recorder, http.StatusOK)
+	var rotated models.TokenResponse
+	decodeBody(t, recorder, &rotated)
+
+	assertErrorCode(t, refreshRequest(t, Refresh, stolen.RefreshToken), http.StatusUnauthorized, models.ErrCodeInvalidToken)
+
+	// После повторного предъявления недействительны и новый токен, и токены других сессий
+	assertErrorCode(t, refreshRequest(t, Refresh, rotated.RefreshToken), http.StatusUnauthorized, models.ErrCodeInvalidToken)
+	assertErrorCode(t, refreshRequest(t, Refresh, other.RefreshToken), http.StatusUnauthorized, models.ErrCodeInvalidToken)
+}
+
+func TestRefreshRejectsExpiredToken(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	setPassword(t, &user)
+	tokens := loginTokens(t, user)
+	services.DB.Model(&models.RefreshToken{}).Where("user_id = ?", user.ID).Update("expires_at", time.Now().Add(-time.Minute))
+
+	assertErrorCode(t, refreshRequest(t, Refresh, tokens.RefreshToken), http.StatusUnauthorized, models.ErrCodeTokenExpired)
+}
+
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	setPassword(t, &user)
+	tokens := loginTokens(t, user)
+
+	assertStatus(t, refreshRequest(t, Logout, tokens.RefreshToken), http.StatusOK)
+	assertErrorCode(t, refreshRequest(t, Refresh, tokens.RefreshToken), http.StatusUnauthorized, models.ErrCodeInvalidToken)
+	assertErrorCode(t, refreshRequest(t, Logout, tokens.RefreshToken), http.StatusUnauthorized, models.ErrCodeInvalidToken)
+}
+
+func TestRefreshDeactivatedUserKeepsToken(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	setPassword(t, &user)
+	tokens := loginTokens(t, user)
+	services.DB.Model(&user).Update("active", false)
+
+	assertErrorCode(t, refreshRequest(t, Refresh, tokens.RefreshToken), http.StatusForbidden, models.ErrCodeAccountDeactivated)
+
+	// Отказ не должен ротировать токен: после реактивации он по-прежнему действителен
+	var revoked int64
+	services.DB.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NOT NULL", user.ID).Count(&revoked)
+	if revoked != 0 {
+		t.Fatalf("%d refresh tokens revoked by a rejected refresh, want 0", revoked)
+	}
+	services.DB.Model(&user).Update("active", true)
+	assertStatus(t, refreshRequest(t, Refresh, tokens.RefreshToken), http.StatusOK)
+}
+
+func TestActiveUserMiddlewareRejectsDeactivatedUser(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	user := createUser(t, models.RoleUser)
+	setPassword(t, &user)
+	tokens := loginTokens(t, user)
+
+	router := gin.New()
+	router.GET("/orders", middlewares.AuthMiddleware(), middlewares.ActiveUserMiddleware(), GetUserOrders)
+	getOrders := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		request.Header.Set("Authorization", "Bearer "+tokens.Token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assertStatus(t, getOrders(), http.StatusOK)
+	assertStatus(t, deleteUser(t, admin, user, ""), http.StatusOK)
+	assertErrorCode(t, getOrders(), http.StatusForbidden, models.ErrCodeAccountDeactivated)
+}
+
+func register(t *testing.T, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, Register, testRequest{method: http.MethodPost, route: "/register", target: "/register", body: body})
+}
+
+func TestRegisterUsernameIsCaseInsensitive(t *testing.T) {
+	setupDB(t)
+
+	assertStatus(t, register(t, models.Credentials{Username: "Alice", Password: testPassword, Email: "alice@example.com"}), http.StatusCreated)
+	assertErrorCode(t, register(t, models.Credentials{Username: "alice", Password: testPassword, Email: "other@example.com"}),
+		http.StatusConflict, models.ErrCodeAlreadyExists)
+
+	var saved models.User
+	if err := services.DB.Where("username = ?", "alice").First(&saved).Error; err != nil {
+		t.Fatalf("registered user not stored in lowercase: %v", err)
+	}
+}
+
+func TestRegisterConcurrentDuplicates(t *testing.T) {
+	setupDB(t)
+
+	const attempts = 5
+	recorders := make([]*httptest.ResponseRecorder, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorders[i] = register(t, models.Credentials{Username: "alice", Password: testPassword, Email: "alice@example.com"})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, recorder := range recorders {
+		if recorder.Code == http.StatusCreated {
+			created++
+			continue
+		}
+		assertErrorCode(t, recorder, http.StatusConflict, models.ErrCodeAlreadyExists)
+	}
+	if created != 1 {
+		t.Fatalf("%d registrations succeeded, want 1", created)
+	}
+}
+
+func TestRegisterReportsAllFieldErrors(t *testing.T) {
+	recorder := register(t, models.Credentials{Username: " a ", Password: "123", Email: "not-an-email"})
+	assertErrorCode(t, recorder, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed)
+
+	var body models.ErrorResponse
+	decodeBody(t, recorder, &body)
+	fields := map[string]bool{}
+	for _, fe := range body.Fields {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{"username", "password", "email"} {
+		if !fields[field] {
+			t.Fatalf("fields = %+v, want an error for %s", body.Fields, field)
+		}
+	}
+}
+
+func TestRegisterRejectsMalformedBody(t *testing.T) {
+	recorder := perform(t, Register, testRequest{method: http.MethodPost, route: "/register", target: "/register", body: "not an object"})
+	assertErrorCode(t, recorder, http.StatusBadRequest, models.ErrCodeValidationFailed)
+}
+
+func TestRegisterIgnoresRequestedRole(t *testing.T) {
+	setupDB(t)
+
+	recorder := register(t, map[string]string{
+		"username": "mallory", "password": testPassword, "email": "mallory@example.com", "role": models.RoleAdmin,
+	})
+	assertStatus(t, recorder, http.StatusCreated)
+
+	var saved models.User
+	if err := services.DB.Where("username = ?", "mallory").First(&saved).Error; err != nil {
+		t.Fatalf("registered user not found: %v", err)
+	}
+	if saved.Role != models.RoleUser {
+		t.Fatalf("role = %s, want %s", saved.Role, models.RoleUser)
+	}
+}
@@ -2,98 +2,191 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"project/models"
 	"project/services"
 	"project/utils"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetCategoriesWithTimeout godoc
 // @Summary Получение списка категорий с тайм-аутом
-// @Description Возвращает список категорий с предзагрузкой связанных продуктов, ограничивая время выполнения запроса до 2 секунд.
+// @Description Возвращает список категорий с пагинацией и количеством продуктов в каждой. При with_products=false продукты не загружаются. Время выполнения запроса ограничено REQUEST_TIMEOUT.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "токен"
-// @Success 200 {array} models.Category "Список категорий с предзагруженными продуктами"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Param with_products query bool false "Загружать продукты категорий" default(true)
+// @Success 200 {object} models.CategoryResponse "Список категорий"
+// @Failure 400 {object} models.ErrorResponse "Некорректные параметры запроса"
 // @Failure 408 {object} models.ErrorResponse "Тайм-аут запроса"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /categories [get]
 func GetCategoriesWithTimeout(c *gin.Context) {
-	// Создаем контекст с тайм-аутом 2 секунды
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
-	defer cancel()
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	withProducts, err := strconv.ParseBool(c.DefaultQuery("with_products", "true"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid with_products value")
+		return
+	}
+
+	var total int64
+	if err := requestDB(c).Model(&models.Category{}).Count(&total).Error; err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			utils.HandleError(c, http.StatusRequestTimeout, models.ErrCodeTimeout, "Request timed out")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch categories")
+		}
+		return
+	}
+
+	query := requestDB(c).
+		Select("categories.*, (SELECT COUNT(*) FROM products WHERE products.category_id = categories.id AND products.deleted_at IS NULL) AS product_count").
+		Order("id asc").
+		Limit(limitInt).
+		Offset((pageInt - 1) * limitInt)
+	if withProducts {
+		query = query.Preload("Products")
+	}
 
 	var categories []models.Category
-	if err := services.DB.WithContext(ctx).Preload("Products").Find(&categories).Error; err != nil {
-		if err == context.DeadlineExceeded {
-			utils.HandleError(c, http.StatusRequestTimeout, "Request timed out")
+	if err := query.Find(&categories).Error; err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			utils.HandleError(c, http.StatusRequestTimeout, models.ErrCodeTimeout, "Request timed out")
 		} else {
-			utils.HandleError(c, http.StatusInternalServerError, "Failed to fetch categories")
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch categories")
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, categories)
+	c.JSON(http.StatusOK, models.CategoryResponse{
+		Data:       categories,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
+	})
 }
 
 // GetCategoryByID godoc
 // @Summary Получение категории по ID
-// @Description Возвращает информацию о категории на основе переданного идентификатора.
+// @Description Возвращает информацию о категории на основе переданного идентификатора. Продукты категории включаются только при expand=products.
+// @Description Поддерживает условный запрос через If-None-Match.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "токен"
 // @Param id path int true "Идентификатор категории"
+// @Param expand query string false "Связи для загрузки через запятую: products"
+// @Param If-None-Match header string false "ETag ранее полученного ответа"
 // @Success 200 {object} models.Category "Информация о категории"
+// @Header 200 {string} ETag "Хеш представления категории"
+// @Success 304 "Категория не изменилась"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 404 {object} models.ErrorResponse "Категория не найдена"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /categories/{id} [get]
 func GetCategoryByID(c *gin.Context) {
 	id := c.Param("id")
+	expand, err := utils.ParseExpand(c, "products")
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	query := requestDB(c)
+	if expand["products"] {
+		query = query.Preload("Products")
+	}
+
 	var category models.Category
-	if err := services.DB.Preload("Products").First(&category, id).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Category not found")
+	if err := query.First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeCategoryNotFound, "Category not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch category")
 		return
 	}
-	c.JSON(http.StatusOK, category)
+
+	if expand["products"] {
+		category.ProductCount = int64(len(category.Products))
+	} else if err := requestDB(c).Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&category.ProductCount).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count category products")
+		return
+	}
+	utils.JSONWithETag(c, category)
 }
 
 // CreateCategory godoc
 // @Summary Создание новой категории
-// @Description Создает новую категорию в базе данных на основе переданных данных.
+// @Description Создает новую категорию в базе данных на основе переданных данных. Название обязательно и должно быть уникальным без учета регистра.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "токен"
 // @Param category body models.Category true "Данные категории"
 // @Success 201 {object} models.Category "Созданная категория"
+// @Header 201 {string} Location "Адрес созданной категории"
 // @Failure 400 {object} models.ErrorResponse "Некорректные данные"
+// @Failure 409 {object} models.ErrorResponse "Категория с таким названием уже существует"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /categories [post]
 func CreateCategory(c *gin.Context) {
 	var newCategory models.Category
-	if err := c.BindJSON(&newCategory); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request")
+	if err := utils.BindAndValidate(c, &newCategory); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	name, err := utils.SanitizeText("name", newCategory.Name)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+	newCategory.Name = strings.TrimSpace(name)
+	if newCategory.Description, err = utils.SanitizeText("description", newCategory.Description); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+	if newCategory.Name == "" {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'name' must not be empty")
 		return
 	}
 
-	if err := services.DB.Create(&newCategory).Error; err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request")
+	if categoryNameTaken(requestDB(c), newCategory.Name, 0) {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Category name already exists")
 		return
 	}
+
+	if err := requestDB(c).Create(&newCategory).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Category name already exists")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create category")
+		}
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/categories/%d", newCategory.ID))
 	c.JSON(http.StatusCreated, newCategory)
 }
 
 // UpdateCategory godoc
 // @Summary Обновление категории
-// @Description Обновляет категорию с переданными данными на основе ID
+// @Description Заменяет название и описание категории по ID; пустое описание очищает его. Название обязательно и должно быть уникальным без учета регистра.
 // @Tags categories
 // @Accept json
 // @Produce json
@@ -103,31 +196,246 @@ func CreateCategory(c *gin.Context) {
 // @Success 200 {object} models.Category "Категория успешно обновлена"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 404 {object} models.ErrorResponse "Категория не найдена"
+// @Failure 409 {object} models.ErrorResponse "Категория с таким названием уже существует"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /categories/{id} [put]
 func UpdateCategory(c *gin.Context) {
 	id := c.Param("id")
 	var updatedCategory models.Category
-	if err := c.BindJSON(&updatedCategory); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request")
+	if err := utils.BindAndValidate(c, &updatedCategory); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	name, err := utils.SanitizeText("name", updatedCategory.Name)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+	updatedCategory.Name = strings.TrimSpace(name)
+	if updatedCategory.Description, err = utils.SanitizeText("description", updatedCategory.Description); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+	if updatedCategory.Name == "" {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'name' must not be empty")
 		return
 	}
 
 	// Проверяем, существует ли категория с этим ID
 	var category models.Category
-	if err := services.DB.First(&category, id).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Category not found")
+	if err := requestDB(c).First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeCategoryNotFound, "Category not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch category")
+		return
+	}
+
+	if categoryNameTaken(requestDB(c), updatedCategory.Name, category.ID) {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Category name already exists")
+		return
+	}
+
+	// Обновляем оба поля явно: Updates со структурой пропустил бы пустое описание
+	if err := requestDB(c).Model(&category).Updates(map[string]interface{}{
+		"name":        updatedCategory.Name,
+		"description": updatedCategory.Description,
+	}).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Category name already exists")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update category")
+		}
 		return
 	}
 
-	// Обновляем категорию
-	if err := services.DB.Model(&category).Updates(updatedCategory).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Failed to update category")
+	if err := requestDB(c).First(&category, category.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch updated category")
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedCategory)
+	c.JSON(http.StatusOK, category)
+}
+
+// categoryNameTaken проверяет, занято ли название другой категорией (без учета регистра)
+// GetCategoryProducts godoc
+// @Summary Получение продуктов категории
+// @Description Возвращает продукты категории в кратком виде с теми же фильтрами, сортировкой и пагинацией, что и список продуктов
+// @Tags categories
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param id path int true "Идентификатор категории"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Param sort query string false "Поле для сортировки: id, name, price, manufacturer, category_id, stock, rating, review_count" default(id)
+// @Param order query string false "Направление сортировки" default(asc)
+// @Param name query string false "Строка поиска по названию и описанию продукта"
+// @Param normalize query bool false "Искать без учета диакритики (é = e)"
+// @Param currency query string false "Валюта, в которой вернуть цены (ISO 4217)"
+// @Success 200 {object} models.ProductResponse "Продукты категории"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Категория не найдена"
+// @Failure 408 {object} models.ErrorResponse "Тайм-аут запроса"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /categories/{id}/products [get]
+func GetCategoryProducts(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid category ID")
+		return
+	}
+
+	var category models.Category
+	if err := requestDB(c).First(&category, categoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeCategoryNotFound, "Category not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch category")
+		}
+		return
+	}
+
+	listProducts(c, func(db *gorm.DB) *gorm.DB {
+		return db.Where("products.category_id = ?", category.ID)
+	})
+}
+
+// PatchCategory godoc
+// @Summary Частичное обновление категории
+// @Description Обновляет только переданные поля категории; пустая строка в description очищает описание.
+// @Description При переданном move_products_to все продукты категории переносятся в указанную категорию в той же транзакции.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param id path int true "Идентификатор категории"
+// @Param category body models.PatchCategoryRequest true "Изменяемые поля категории"
+// @Success 200 {object} models.PatchCategoryResponse "Категория и число перенесенных продуктов"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос или неизвестная категория для переноса"
+// @Failure 404 {object} models.ErrorResponse "Категория не найдена"
+// @Failure 409 {object} models.ErrorResponse "Категория с таким названием уже существует"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /categories/{id} [patch]
+func PatchCategory(c *gin.Context) {
+	var request models.PatchCategoryRequest
+	if err := utils.BindJSONStrict(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Invalid request: "+err.Error())
+		return
+	}
+
+	var category models.Category
+	if err := requestDB(c).First(&category, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeCategoryNotFound, "Category not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch category")
+		}
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if request.Name != nil {
+		name, err := utils.SanitizeText("name", *request.Name)
+		if err != nil {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+			return
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'name' must not be empty")
+			return
+		}
+		if categoryNameTaken(requestDB(c), name, category.ID) {
+			utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Category name already exists")
+			return
+		}
+		updates["name"] = name
+	}
+	if request.Description != nil {
+		description, err := utils.SanitizeText("description", *request.Description)
+		if err != nil {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+			return
+		}
+		updates["description"] = description
+	}
+
+	if request.MoveProductsTo != nil {
+		if *request.MoveProductsTo == category.ID {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'move_products_to' must differ from the category being updated")
+			return
+		}
+		var target models.Category
+		if err := requestDB(c).First(&target, *request.MoveProductsTo).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCategoryNotFound, "Field 'move_products_to' refers to unknown category")
+			} else {
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch category")
+			}
+			return
+		}
+	}
+
+	tx := requestDB(c).Begin()
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start transaction")
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&category).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Category name already exists")
+			} else {
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update category")
+			}
+			return
+		}
+	}
+
+	var moved int64
+	if request.MoveProductsTo != nil {
+		// Перенос меняет продукты, поэтому их версия увеличивается, как при обычном обновлении
+		result := tx.Model(&models.Product{}).
+			Where("category_id = ?", category.ID).
+			Updates(map[string]interface{}{
+				"category_id": *request.MoveProductsTo,
+				"version":     gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to move products")
+			return
+		}
+		moved = result.RowsAffected
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update category")
+		return
+	}
+
+	if err := requestDB(c).First(&category, category.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch updated category")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PatchCategoryResponse{
+		Category:      category,
+		MovedProducts: moved,
+	})
+}
+
+func categoryNameTaken(db *gorm.DB, name string, exceptID int) bool {
+	var count int64
+	db.Model(&models.Category{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, exceptID).Count(&count)
+	return count > 0
 }
 
 // DeleteCategory godoc
@@ -139,14 +447,30 @@ func UpdateCategory(c *gin.Context) {
 // @Param Authorization header string false "токен"
 // @Param id path int true "Идентификатор категории"
 // @Success 200 {object} models.MessageResponse "Категория успешно удалена"
+// @Failure 400 {object} models.ErrorResponse "Некорректный ID категории"
 // @Failure 404 {object} models.ErrorResponse "Категория не найдена"
+// @Failure 409 {object} models.ErrorResponse "Категорию для продуктов без категории удалить нельзя"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /categories/{id} [delete]
 func DeleteCategory(c *gin.Context) {
-	id := c.Param("id")
-	if err := services.DB.Delete(&models.Category{}, id).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Category not found")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid category ID")
+		return
+	}
+	if id == services.UncategorizedCategoryID {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeConflict, "The uncategorized category cannot be deleted")
+		return
+	}
+	result := requestDB(c).Delete(&models.Category{}, id)
+	if result.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete category")
+		return
+	}
+	// Delete не возвращает ErrRecordNotFound, отсутствие категории видно только по числу удаленных строк
+	if result.RowsAffected == 0 {
+		utils.HandleError(c, http.StatusNotFound, models.ErrCodeCategoryNotFound, "Category not found")
 		return
 	}
 	c.JSON(http.StatusOK, models.MessageResponse{
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"project/models"
+	"project/services"
+	"testing"
+)
+
+func TestDeleteCategoryKeepsUncategorized(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+
+	// Ведущий ноль не должен обходить проверку
+	for _, id := range []string{fmt.Sprint(services.UncategorizedCategoryID), fmt.Sprintf("0%d", services.UncategorizedCategoryID)} {
+		recorder := perform(t, DeleteCategory, testRequest{
+			method: http.MethodDelete, route: "/categories/:id", target: "/categories/" + id, user: &admin,
+		})
+		assertErrorCode(t, recorder, http.StatusConflict, models.ErrCodeConflict)
+	}
+
+	var count int64
+	services.DB.Model(&models.Category{}).Where("id = ?", services.UncategorizedCategoryID).Count(&count)
+	if count != 1 {
+		t.Fatal("uncategorized category was deleted")
+	}
+
+	recorder := perform(t, DeleteCategory, testRequest{
+		method: http.MethodDelete, route: "/categories/:id", target: "/categories/abc", user: &admin,
+	})
+	assertErrorCode(t, recorder, http.StatusBadRequest, models.ErrCodeInvalidRequest)
+}
+
+func TestRepairOrphanedProducts(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	// Внешний ключ не дает удалить категорию с продуктами: сироты остаются в базах, созданных без него
+	migrator := services.DB.Migrator()
+	if migrator.HasConstraint(&models.Category{}, "Products") {
+		if err := migrator.DropConstraint(&models.Category{}, "Products"); err != nil {
+			t.Fatalf("drop category constraint: %v", err)
+		}
+		t.Cleanup(func() {
+			services.DB.Exec("TRUNCATE products RESTART IDENTITY CASCADE")
+			migrator.CreateConstraint(&models.Category{}, "Products")
+		})
+	}
+	category := models.Category{Name: "Removed"}
+	services.DB.Create(&category)
+	orphan := createProduct(t, 10, 1)
+	kept := createProduct(t, 20, 1)
+	services.DB.Model(&orphan).Update("category_id", category.ID)
+	services.DB.Delete(&category)
+
+	recorder := perform(t, RepairOrphanedProducts, testRequest{
+		method: http.MethodPost, route: "/admin/products/orphaned/repair", target: "/admin/products/orphaned/repair", user: &admin,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	var response models.RepairOrphanedProductsResponse
+	decodeBody(t, recorder, &response)
+	if response.Updated != 1 || response.CategoryID != services.UncategorizedCategoryID {
+		t.Fatalf("response = %+v, want one product moved to %d", response, services.UncategorizedCategoryID)
+	}
+
+	repaired := reloadProduct(t, orphan.ID)
+	if repaired.CategoryID != services.UncategorizedCategoryID || repaired.Version != orphan.Version+1 {
+		t.Fatalf("repaired product category/version = %d/%d", repaired.CategoryID, repaired.Version)
+	}
+	if untouched := reloadProduct(t, kept.ID); untouched.Version != kept.Version {
+		t.Fatal("product with an existing category was updated")
+	}
+	entry := assertAudit(t, admin, models.AuditActionProductsOrphanRepair, fmt.Sprintf("category:%d", services.UncategorizedCategoryID))
+	if entry.Details["ids"] != fmt.Sprint(orphan.ID) {
+		t.Fatalf("details = %v, want ids %d", entry.Details, orphan.ID)
+	}
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project/models"
+	"project/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetCoupons godoc
+// @Summary Получение списка купонов
+// @Description Возвращает все купоны со сроками действия и счетчиками использований
+// @Tags coupons
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Success 200 {array} models.Coupon "Список купонов"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/coupons [get]
+func GetCoupons(c *gin.Context) {
+	var coupons []models.Coupon
+	if err := requestDB(c).Order("id asc").Find(&coupons).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch coupons")
+		return
+	}
+	c.JSON(http.StatusOK, coupons)
+}
+
+// GetCouponByID godoc
+// @Summary Получение купона по ID
+// @Description Возвращает купон по идентификатору
+// @Tags coupons
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param id path int true "Идентификатор купона"
+// @Success 200 {object} models.Coupon "Купон"
+// @Failure 404 {object} models.ErrorResponse "Купон не найден"
+// @Security BearerAuth
+// @Router /admin/coupons/{id} [get]
+func GetCouponByID(c *gin.Context) {
+	id := c.Param("id")
+	var coupon models.Coupon
+	if err := requestDB(c).First(&coupon, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeCouponNotFound, "Coupon not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch coupon")
+		return
+	}
+	c.JSON(http.StatusOK, coupon)
+}
+
+// CreateCoupon godoc
+// @Summary Создание купона
+// @Description Создает купон с процентной (percent) или фиксированной (fixed) скидкой
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param coupon body models.Coupon true "Данные купона"
+// @Success 201 {object} models.Coupon "Созданный купон"
+// @Header 201 {string} Location "Адрес созданного купона"
+// @Failure 400 {object} models.ErrorResponse "Некорректные данные"
+// @Failure 409 {object} models.ErrorResponse "Купон с таким кодом уже существует"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/coupons [post]
+func CreateCoupon(c *gin.Context) {
+	var newCoupon models.Coupon
+	if err := utils.BindAndValidate(c, &newCoupon); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	newCoupon.Code = strings.ToUpper(strings.TrimSpace(newCoupon.Code))
+	newCoupon.UsedCount = 0
+	if err := validateCoupon(newCoupon); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	var existing models.Coupon
+	if err := requestDB(c).Where("code = ?", newCoupon.Code).First(&existing).Error; err == nil {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Coupon code already exists")
+		return
+	}
+
+	if err := requestDB(c).Create(&newCoupon).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create coupon")
+		return
+	}
+	recordAudit(c, models.AuditActionCouponCreate, fmt.Sprintf("coupon:%d", newCoupon.ID), models.StringMap{
+		"code": newCoupon.Code,
+	})
+	c.Header("Location", fmt.Sprintf("/admin/coupons/%d", newCoupon.ID))
+	c.JSON(http.StatusCreated, newCoupon)
+}
+
+// UpdateCoupon godoc
+// @Summary Обновление купона
+// @Description Обновляет параметры купона. Счетчик использований не изменяется.
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param id path int true "Идентификатор купона"
+// @Param coupon body models.Coupon true "Обновленные данные купона"
+// @Success 200 {object} models.Coupon "Обновленный купон"
+// @Failure 400 {object} models.ErrorResponse "Некорректные данные"
+// @Failure 404 {object} models.ErrorResponse "Купон не найден"
+// @Failure 409 {object} models.ErrorResponse "Купон с таким кодом уже существует"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/coupons/{id} [put]
+func UpdateCoupon(c *gin.Context) {
+	id := c.Param("id")
+	var updatedCoupon models.Coupon
+	if err := utils.BindAndValidate(c, &updatedCoupon); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	var coupon models.Coupon
+	if err := requestDB(c).First(&coupon, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeCouponNotFound, "Coupon not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch coupon")
+		return
+	}
+
+	updatedCoupon.Code = strings.ToUpper(strings.TrimSpace(updatedCoupon.Code))
+	if err := validateCoupon(updatedCoupon); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	var existing models.Coupon
+	if err := requestDB(c).Where("code = ? AND id <> ?", updatedCoupon.Code, coupon.ID).First(&existing).Error; err == nil {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Coupon code already exists")
+		return
+	}
+
+	if err := requestDB(c).Model(&coupon).Updates(map[string]interface{}{
+		"code":        updatedCoupon.Code,
+		"type":        updatedCoupon.Type,
+		"value":       updatedCoupon.Value,
+		"expires_at":  updatedCoupon.ExpiresAt,
+		"usage_limit": updatedCoupon.UsageLimit,
+	}).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update coupon")
+		return
+	}
+	recordAudit(c, models.AuditActionCouponUpdate, fmt.Sprintf("coupon:%d", coupon.ID), models.StringMap{
+		"code": updatedCoupon.Code,
+	})
+
+	if err := requestDB(c).First(&coupon, coupon.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch updated coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+// DeleteCoupon godoc
+// @Summary Удаление купона
+// @Description Удаляет купон. Скидки в уже оформленных заказах сохраняются.
+// @Tags coupons
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param id path int true "Идентификатор купона"
+// @Success 200 {object} models.MessageResponse "Купон удален"
+// @Failure 404 {object} models.ErrorResponse "Купон не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/coupons/{id} [delete]
+func DeleteCoupon(c *gin.Context) {
+	id := c.Param("id")
+	result := requestDB(c).Delete(&models.Coupon{}, id)
+	if result.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete coupon")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.HandleError(c, http.StatusNotFound, models.ErrCodeCouponNotFound, "Coupon not found")
+		return
+	}
+	recordAudit(c, models.AuditActionCouponDelete, "coupon:"+id, nil)
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "coupon deleted",
+	})
+}
+
+// validateCoupon проверяет код, тип и размер скидки купона
+func validateCoupon(coupon models.Coupon) error {
+	if coupon.Code == "" {
+		return errors.New("Field 'code' must not be empty")
+	}
+
+	switch coupon.Type {
+	case models.CouponTypePercent:
+		if coupon.Value <= 0 || coupon.Value > 100 {
+			return errors.New("Field 'value' must be between 0 and 100 for percent coupons")
+		}
+	case models.CouponTypeFixed:
+		if coupon.Value <= 0 {
+			return errors.New("Field 'value' must be greater than 0")
+		}
+	default:
+		return errors.New("Field 'type' must be 'percent' or 'fixed'")
+	}
+
+	if coupon.UsageLimit < 0 {
+		return errors.New("Field 'usage_limit' must not be negative")
+	}
+	return nil
+}
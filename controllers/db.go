@@ -0,0 +1,14 @@
+package controllers
+
+import (
+	"project/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestDB возвращает соединение с базой, привязанное к контексту запроса: запросы прерываются,
+// когда TimeoutMiddleware отменяет контекст или клиент закрывает соединение
+func requestDB(c *gin.Context) *gorm.DB {
+	return services.DB.WithContext(c.Request.Context())
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"project/models"
+	"project/services"
+	"project/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz godoc
+// @Summary Проверка работоспособности сервиса
+// @Description Всегда возвращает 200, если процесс запущен и обрабатывает запросы.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.MessageResponse "Сервис работает"
+// @Router /healthz [get]
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "ok",
+	})
+}
+
+// Readyz godoc
+// @Summary Проверка готовности сервиса
+// @Description Проверяет доступность базы данных. Возвращает 503, если база данных недоступна.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.MessageResponse "Сервис готов принимать запросы"
+// @Failure 503 {object} models.ErrorResponse "База данных недоступна"
+// @Router /readyz [get]
+func Readyz(c *gin.Context) {
+	if services.DB == nil {
+		utils.HandleError(c, http.StatusServiceUnavailable, models.ErrCodeUnavailable, "database is not initialized")
+		return
+	}
+
+	sqlDB, err := services.DB.DB()
+	if err != nil {
+		utils.HandleError(c, http.StatusServiceUnavailable, models.ErrCodeUnavailable, "database is unavailable")
+		return
+	}
+
+	// Ограничиваем время проверки, чтобы балансировщик не ждал зависшее соединение
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		utils.HandleError(c, http.StatusServiceUnavailable, models.ErrCodeUnavailable, "database is unavailable")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "ready",
+	})
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"net/http"
+	"project/models"
+	"project/services"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// replaceDB подменяет соединение с базой на время теста
+func replaceDB(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	saved := services.DB
+	services.DB = db
+	t.Cleanup(func() { services.DB = saved })
+}
+
+// useUnreachableDB подменяет базу соединением, которое не может подключиться:
+// порт 1 заведомо не принимает соединения, автоматический ping при открытии отключен
+func useUnreachableDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 user=test dbname=test sslmode=disable connect_timeout=1"),
+		&gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	replaceDB(t, db)
+	return db
+}
+
+func readyz(t *testing.T) int {
+	t.Helper()
+	recorder := perform(t, Readyz, testRequest{method: http.MethodGet, route: "/readyz", target: "/readyz"})
+	if recorder.Code != http.StatusOK {
+		assertErrorCode(t, recorder, http.StatusServiceUnavailable, models.ErrCodeUnavailable)
+	}
+	return recorder.Code
+}
+
+func TestReadyzWithoutDatabase(t *testing.T) {
+	replaceDB(t, nil)
+	if code := readyz(t); code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestReadyzWithUnreachableDatabase(t *testing.T) {
+	db := useUnreachableDB(t)
+	if code := readyz(t); code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+	if code := readyz(t); code != http.StatusServiceUnavailable {
+		t.Fatalf("status after close = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	setupDB(t)
+	if code := readyz(t); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"project/services"
+	"testing"
+)
+
+func createOrderWithKey(t *testing.T, user models.User, key string, lines ...models.ProductInOrder) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, CreateOrder, testRequest{
+		method: http.MethodPost, route: "/orders", target: "/orders", user: &user,
+		body:   models.CreateOrderRequest{Products: lines},
+		header: map[string]string{idempotencyKeyHeader: key},
+	})
+}
+
+func countOrders(t *testing.T, user models.User) int64 {
+	t.Helper()
+	var count int64
+	services.DB.Model(&models.Order{}).Where("user_id = ?", user.ID).Count(&count)
+	return count
+}
+
+func TestCreateOrderRepeatedIdempotencyKey(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	line := models.ProductInOrder{ProductID: product.ID, Quantity: 2}
+
+	recorder := createOrderWithKey(t, user, "checkout-1", line)
+	assertStatus(t, recorder, http.StatusCreated)
+	var first models.Order
+	decodeBody(t, recorder, &first)
+
+	recorder = createOrderWithKey(t, user, "checkout-1", line)
+	assertStatus(t, recorder, http.StatusOK)
+	var repeated models.Order
+	decodeBody(t, recorder, &repeated)
+
+	if repeated.ID != first.ID {
+		t.Fatalf("repeated request returned order %d, want %d", repeated.ID, first.ID)
+	}
+	if count := countOrders(t, user); count != 1 {
+		t.Fatalf("%d orders created, want 1", count)
+	}
+	if got := reloadProduct(t, product.ID); got.Reserved != 2 {
+		t.Fatalf("reserved = %d, want 2", got.Reserved)
+	}
+}
+
+func TestCreateOrderIdempotencyKeyWithDifferentPayload(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	other := createProduct(t, 20, 5)
+
+	recorder := createOrderWithKey(t, user, "checkout-1", models.ProductInOrder{ProductID: product.ID, Quantity: 2})
+	assertStatus(t, recorder, http.StatusCreated)
+	var first models.Order
+	decodeBody(t, recorder, &first)
+
+	// Ключ определяет запрос целиком: новое тело игнорируется, возвращается исходный заказ
+	recorder = createOrderWithKey(t, user, "checkout-1", models.ProductInOrder{ProductID: other.ID, Quantity: 1})
+	assertStatus(t, recorder, http.StatusOK)
+	var repeated models.Order
+	decodeBody(t, recorder, &repeated)
+
+	if repeated.ID != first.ID {
+		t.Fatalf("reused key returned order %d, want %d", repeated.ID, first.ID)
+	}
+	if len(repeated.Products) != 1 || repeated.Products[0].ProductID != product.ID {
+		t.Fatalf("reused key returned lines %+v, want the original line", repeated.Products)
+	}
+	if count := countOrders(t, user); count != 1 {
+		t.Fatalf("%d orders created, want 1", count)
+	}
+	if got := reloadProduct(t, other.ID); got.Reserved != 0 {
+		t.Fatalf("product from the ignored payload reserved %d, want 0", got.Reserved)
+	}
+}
+
+func TestCreateOrderIdempotencyKeyIsPerUser(t *testing.T) {
+	setupDB(t)
+	product := createProduct(t, 10, 5)
+	line := models.ProductInOrder{ProductID: product.ID, Quantity: 1}
+
+	for _, user := range []models.User{createUser(t, models.RoleUser), createUser(t, models.RoleUser)} {
+		assertStatus(t, createOrderWithKey(t, user, "checkout-1", line), http.StatusCreated)
+	}
+}
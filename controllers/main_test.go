@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"project/models"
+	"project/services"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Тесты контроллеров работают с настоящей базой PostgreSQL: TEST_DATABASE_DSN должен
+// указывать на отдельную базу, все таблицы которой очищаются перед каждым тестом.
+// Без этой переменной тесты, которым нужна база, пропускаются
+var testDBAvailable bool
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if dsn := os.Getenv("TEST_DATABASE_DSN"); dsn != "" {
+		services.AppConfig.DBConnectAttempts = 1
+		if err := services.ConnectDB(dsn); err != nil {
+			log.Fatal(err)
+		}
+		testDBAvailable = true
+	}
+
+	code := m.Run()
+	services.CloseDB()
+	os.Exit(code)
+}
+
+// setupDB очищает все таблицы тестовой базы и заново создает обязательные записи
+func setupDB(t *testing.T) {
+	t.Helper()
+	if !testDBAvailable {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	tables, err := services.DB.Migrator().GetTables()
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = `"` + table + `"`
+	}
+	if err := services.DB.Exec("TRUNCATE " + strings.Join(quoted, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+		t.Fatalf("truncate tables: %v", err)
+	}
+	if err := services.EnsureUncategorizedCategory(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testRequest описывает запрос к одному обработчику; user подставляется вместо проверки токена
+type testRequest struct {
+	method string
+	route  string
+	target string
+	user   *models.User
+	body   interface{}
+	header map[string]string
+}
+
+func perform(t *testing.T, handler gin.HandlerFunc, req testRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	router := gin.New()
+	router.Handle(req.method, req.route, func(c *gin.Context) {
+		if req.user != nil {
+			c.Set("user_id", req.user.ID)
+			c.Set("role", req.user.Role)
+		}
+		c.Next()
+	}, handler)
+
+	var body io.Reader
+	if req.body != nil {
+		data, err := json.Marshal(req.body)
+		if err != nil {
+			t.Fatalf("encode request body: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	httpRequest := httptest.NewRequest(req.method, req.target, body)
+	httpRequest.Header.Set("Content-Type", "application/json")
+	for name, value := range req.header {
+		httpRequest.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httpRequest)
+	return recorder
+}
+
+func decodeBody(t *testing.T, recorder *httptest.ResponseRecorder, out interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+		t.Fatalf("decode response %q: %v", recorder.Body.String(), err)
+	}
+}
+
+func assertStatus(t *testing.T, recorder *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if recorder.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", recorder.Code, want, recorder.Body.String())
+	}
+}
+
+func assertErrorCode(t *testing.T, recorder *httptest.ResponseRecorder, status int, code models.ErrorCode) {
+	t.Helper()
+	assertStatus(t, recorder, status)
+	var body models.ErrorResponse
+	decodeBody(t, recorder, &body)
+	if body.ErrorCode != code {
+		t.Fatalf("error_code = %s, want %s", body.ErrorCode, code)
+	}
+}
+
+func createUser(t *testing.T, role string) models.User {
+	t.Helper()
+	var count int64
+	services.DB.Model(&models.User{}).Count(&count)
+	name := fmt.Sprintf("user%d", count+1)
+	user := models.User{Username: name, Email: name + "@example.com", Password: "-", Role: role, Active: true}
+	if err := services.DB.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func createProduct(t *testing.T, price float64, stock int) models.Product {
+	t.Helper()
+	product := models.Product{
+		Name:       fmt.Sprintf("Product %.2f/%d", price, stock),
+		CategoryID: services.UncategorizedCategoryID,
+		Price:      price,
+		Currency:   services.AppConfig.BaseCurrency,
+		Stock:      stock,
+	}
+	if err := services.DB.Create(&product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	return product
+}
+
+// createOrder создает заказ с позициями как есть, без резервов и списания остатков
+func createOrder(t *testing.T, user models.User, status string, lines ...models.OrderProduct) models.Order {
+	t.Helper()
+	order := models.Order{UserID: user.ID, Status: status}
+	if err := services.DB.Create(&order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	for _, line := range lines {
+		line.OrderID = order.ID
+		if err := services.DB.Omit("Product", "Variant").Create(&line).Error; err != nil {
+			t.Fatalf("create order line: %v", err)
+		}
+	}
+	return order
+}
+
+func reloadProduct(t *testing.T, id int) models.Product {
+	t.Helper()
+	var product models.Product
+	if err := services.DB.Unscoped().First(&product, id).Error; err != nil {
+		t.Fatalf("reload product %d: %v", id, err)
+	}
+	return product
+}
@@ -1,27 +1,166 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"project/models"
 	"project/services"
 	"project/utils"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// idempotencyKeyHeader — заголовок, по которому повторные запросы на создание заказа не создают дубликатов
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// withOrderProducts подгружает позиции заказа вместе с продуктами,
+// включая мягко удаленные, чтобы история заказов оставалась полной
+func withOrderProducts(db *gorm.DB) *gorm.DB {
+	return db.Preload("Products.Product", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Products.Variant", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	})
+}
+
+// orderTotalTolerance — допустимое расхождение суммы заказа с expected_total из-за округления на клиенте
+const orderTotalTolerance = 0.01
+
+// orderLinePrice — SQL-выражение цены единицы позиции заказа. Для позиций, созданных до сохранения цены покупки,
+// берется текущая цена варианта или продукта
+const orderLinePrice = "COALESCE(NULLIF(order_products.price_at_purchase, 0), product_variants.price, products.price)"
+
+// currentLinePrice возвращает текущую цену единицы позиции: цену варианта, если она задана, иначе цену продукта
+func currentLinePrice(tx *gorm.DB, productID int, variantID *int) (float64, error) {
+	if variantID != nil {
+		var variant models.ProductVariant
+		if err := tx.First(&variant, *variantID).Error; err != nil {
+			return 0, err
+		}
+		if variant.Price != nil {
+			return *variant.Price, nil
+		}
+	}
+
+	var product models.Product
+	if err := tx.Select("price").First(&product, productID).Error; err != nil {
+		return 0, err
+	}
+	return product.Price, nil
+}
+
+// checkLineVariant проверяет вариант позиции заказа: запрошенный вариант должен принадлежать продукту
+// и совпадать с вариантом уже существующей позиции, а остатка варианта должно хватать на всю позицию.
+// При ошибке отвечает клиенту
+func checkLineVariant(c *gin.Context, tx *gorm.DB, line *models.OrderProduct, requested *int, isNew bool) bool {
+	if requested != nil {
+		if !isNew && (line.VariantID == nil || *line.VariantID != *requested) {
+			utils.HandleError(c, http.StatusConflict, models.ErrCodeConflict, fmt.Sprintf("Product %d is already in the order with a different variant", line.ProductID))
+			return false
+		}
+		line.VariantID = requested
+	}
+	if line.VariantID == nil {
+		return true
+	}
+
+	var variant models.ProductVariant
+	if err := tx.Where("id = ? AND product_id = ?", *line.VariantID, line.ProductID).First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeVariantNotFound, fmt.Sprintf("Variant %d of product %d not found", *line.VariantID, line.ProductID))
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product variant")
+		}
+		return false
+	}
+
+	if variant.Stock < line.Quantity {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeInsufficientStock, fmt.Sprintf("Insufficient stock for variant %d", variant.ID))
+		return false
+	}
+	return true
+}
+
+// checkOrderLimits проверяет, что заказ не превышает допустимое число позиций и общее количество товаров.
+// Вызывается в транзакции после изменения позиций; при превышении отвечает клиенту 400
+func checkOrderLimits(c *gin.Context, tx *gorm.DB, orderID int) bool {
+	var totals struct {
+		Lines    int
+		Quantity int
+	}
+	if err := tx.Model(&models.OrderProduct{}).
+		Select("COUNT(*) AS lines, COALESCE(SUM(quantity), 0) AS quantity").
+		Where("order_id = ?", orderID).
+		Scan(&totals).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error checking order limits")
+		return false
+	}
+
+	if totals.Lines > services.AppConfig.MaxOrderLines {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeOrderLimitExceeded, fmt.Sprintf("Order must not contain more than %d different products", services.AppConfig.MaxOrderLines))
+		return false
+	}
+	if totals.Quantity > services.AppConfig.MaxOrderQuantity {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeOrderLimitExceeded, fmt.Sprintf("Order must not contain more than %d items in total", services.AppConfig.MaxOrderQuantity))
+		return false
+	}
+	return true
+}
+
+// handleClosedOrder отвечает клиенту, что заказ больше нельзя изменять
+func handleClosedOrder(c *gin.Context, status string) {
+	if status == models.OrderStatusCancelled {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeOrderCancelled, "Order has been cancelled")
+		return
+	}
+	utils.HandleError(c, http.StatusConflict, models.ErrCodeOrderCheckedOut, "Order has already been checked out")
+}
+
+// handleReservationError отвечает клиенту на ошибку резервирования товара
+func handleReservationError(c *gin.Context, err error, productID int) {
+	if errors.Is(err, services.ErrInsufficientStock) {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeInsufficientStock, fmt.Sprintf("Insufficient stock for product %d", productID))
+		return
+	}
+	utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error reserving stock")
+}
+
+// handleVariantStockError отвечает клиенту на ошибку списания остатка варианта при оформлении заказа
+func handleVariantStockError(c *gin.Context, err error, variantID int) {
+	switch {
+	case errors.Is(err, services.ErrInsufficientStock):
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeInsufficientStock, fmt.Sprintf("Insufficient stock for variant %d", variantID))
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeVariantNotFound, fmt.Sprintf("Variant %d is no longer available", variantID))
+	default:
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deducting variant stock")
+	}
+}
+
 // CreateOrder godoc
 // @Summary Создание нового заказа
 // @Description Создает новый заказ и связывает с ним продукты. Если продукты не указаны, заказ будет создан без них.
+// @Description При повторе запроса с тем же заголовком Idempotency-Key возвращается исходный заказ вместо создания нового.
+// @Description Позиции заказа резервируются на складе до оформления заказа или истечения срока резерва.
+// @Description Если передан expected_total и он расходится с рассчитанной суммой заказа больше чем на 0.01, заказ не создается и возвращается 409 с обеими суммами.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "JWT токен пользователя"
+// @Param Idempotency-Key header string false "Ключ идемпотентности запроса"
 // @Param request body models.CreateOrderRequest true "Данные для создания заказа"
-// @Success 200 {object} models.MessageResponse "Заказ успешно создан"
+// @Success 200 {object} models.Order "Заказ, ранее созданный с этим ключом идемпотентности"
+// @Success 201 {object} models.Order "Созданный заказ с позициями"
+// @Header 201 {string} Location "Адрес созданного заказа"
 // @Failure 400 {object} models.ErrorResponse "Некорректные данные запроса или продукт не найден"
 // @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 409 {object} models.ErrorResponse "Недостаточно товара на складе или сумма заказа не совпала с expected_total"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /orders [post]
@@ -29,69 +168,94 @@ func CreateOrder(c *gin.Context) {
 	var request models.CreateOrderRequest
 
 	// Чтение данных из запроса
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request data")
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	// Получаем user_id из контекста (из JWT токена)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	idempotencyKey := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+	if len(idempotencyKey) > 255 {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Idempotency key is too long")
 		return
 	}
 
+	if idempotencyKey != "" {
+		existing, found, err := findIdempotentOrder(requestDB(c), userID.(int), idempotencyKey)
+		if err != nil {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+			return
+		}
+		if found {
+			c.Header("Location", fmt.Sprintf("/orders/%d", existing.ID))
+			c.JSON(http.StatusOK, existing)
+			return
+		}
+	}
+
 	// Создаем новый заказ
 	order := models.Order{
 		UserID: userID.(int),
+		Status: models.OrderStatusPending,
 	}
 
-	tx := services.DB.Begin()
+	tx := requestDB(c).Begin()
 
 	if tx.Error != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error starting transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
 		return
 	}
 
 	if err := tx.Create(&order).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Error creating order")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating order")
 		return
 	}
 
 	if len(request.Products) > 0 {
 		for _, p := range request.Products {
-			var product models.Product
-			if err := tx.First(&product, p.ProductID).Error; err != nil {
+			if p.Quantity < 1 {
 				tx.Rollback()
-				utils.HandleError(c, http.StatusBadRequest, fmt.Sprintf("Product with ID %d not found", p.ProductID))
+				utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, fmt.Sprintf("Quantity for product %d must be greater than zero", p.ProductID))
 				return
 			}
 
-			if p.Quantity < 1 {
+			var product models.Product
+			if err := tx.First(&product, p.ProductID).Error; err != nil {
 				tx.Rollback()
-				utils.HandleError(c, http.StatusBadRequest, "Quantity must be greater then zero")
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					utils.HandleError(c, http.StatusBadRequest, models.ErrCodeProductNotFound, fmt.Sprintf("Product with ID %d not found", p.ProductID))
+					return
+				}
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product")
 				return
 			}
 
 			var orderProduct models.OrderProduct
 			if err := tx.Where("order_id = ? AND product_id = ?", order.ID, p.ProductID).First(&orderProduct).Error; err == nil {
-				// Если продукт найден, обновляем его количество
+				// Если продукт уже указан в запросе, увеличиваем его количество
 				orderProduct.Quantity += p.Quantity
-				if err := services.DB.Save(&orderProduct).Error; err != nil {
+				if !checkLineVariant(c, tx, &orderProduct, p.VariantID, false) {
 					tx.Rollback()
-					utils.HandleError(c, http.StatusInternalServerError, "Error updating product quantity")
 					return
 				}
-
-				if err := tx.Commit().Error; err != nil {
-					utils.HandleError(c, http.StatusInternalServerError, "Internal server error")
+				if err := tx.Save(&orderProduct).Error; err != nil {
+					tx.Rollback()
+					utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating product quantity")
+					return
 				}
-
-				c.JSON(http.StatusOK, models.MessageResponse{
-					Message: "Product quantity updated",
-				})
-				return
+				if err := services.SetReservation(tx, order.ID, p.ProductID, orderProduct.Quantity); err != nil {
+					tx.Rollback()
+					handleReservationError(c, err, p.ProductID)
+					return
+				}
+				continue
 			}
 
 			orderProduct = models.OrderProduct{
@@ -99,34 +263,130 @@ func CreateOrder(c *gin.Context) {
 				ProductID: p.ProductID,
 				Quantity:  p.Quantity,
 			}
+			if !checkLineVariant(c, tx, &orderProduct, p.VariantID, true) {
+				tx.Rollback()
+				return
+			}
+			price, err := currentLinePrice(tx, orderProduct.ProductID, orderProduct.VariantID)
+			if err != nil {
+				tx.Rollback()
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product price")
+				return
+			}
+			orderProduct.PriceAtPurchase = price
 
 			if err := tx.Create(&orderProduct).Error; err != nil {
 				tx.Rollback()
-				utils.HandleError(c, http.StatusInternalServerError, "Error creating order product")
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating order product")
+				return
+			}
+
+			if err := services.SetReservation(tx, order.ID, p.ProductID, orderProduct.Quantity); err != nil {
+				tx.Rollback()
+				handleReservationError(c, err, p.ProductID)
+				return
+			}
+		}
+	}
+
+	if idempotencyKey != "" {
+		// Просроченный ключ или ключ удаленного заказа освобождается для повторного использования
+		if err := tx.Where("user_id = ? AND key = ?", order.UserID, idempotencyKey).
+			Where("created_at < ? OR order_id NOT IN (SELECT id FROM orders)", time.Now().Add(-services.AppConfig.IdempotencyKeyTTL)).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating order")
+			return
+		}
+
+		if err := tx.Create(&models.IdempotencyKey{UserID: order.UserID, Key: idempotencyKey, OrderID: order.ID}).Error; err != nil {
+			tx.Rollback()
+
+			// Параллельный запрос с тем же ключом успел создать заказ первым
+			existing, found, findErr := findIdempotentOrder(requestDB(c), order.UserID, idempotencyKey)
+			if findErr != nil || !found {
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating order")
 				return
 			}
+			c.Header("Location", fmt.Sprintf("/orders/%d", existing.ID))
+			c.JSON(http.StatusOK, existing)
+			return
+		}
+	}
+
+	if !checkOrderLimits(c, tx, order.ID) {
+		tx.Rollback()
+		return
+	}
+
+	if request.ExpectedTotal != nil {
+		if err := fillOrderTotals(tx, &order); err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error calculating order total")
+			return
+		}
+		// Расхождение означает, что цены изменились после того, как клиент показал сумму
+		if math.Abs(order.Total-*request.ExpectedTotal) > orderTotalTolerance {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusConflict, models.ErrCodeTotalMismatch,
+				fmt.Sprintf("Order total %.2f does not match expected total %.2f", order.Total, *request.ExpectedTotal))
+			return
 		}
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error committing transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
 		return
 	}
 
-	c.JSON(http.StatusOK, models.MessageResponse{
-		Message: fmt.Sprintf("Order created successfully. Order ID: %d", order.ID),
-	})
+	// Загружаем созданный заказ вместе с позициями и продуктами
+	if err := requestDB(c).Scopes(withOrderProducts).First(&order, order.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching created order")
+		return
+	}
+
+	services.NotifyOrderPlaced(order)
+
+	c.Header("Location", fmt.Sprintf("/orders/%d", order.ID))
+	c.JSON(http.StatusCreated, order)
+}
+
+// findIdempotentOrder ищет заказ пользователя, созданный с непросроченным ключом идемпотентности
+func findIdempotentOrder(db *gorm.DB, userID int, key string) (models.Order, bool, error) {
+	var record models.IdempotencyKey
+	err := db.Where("user_id = ? AND key = ? AND created_at >= ?", userID, key, time.Now().Add(-services.AppConfig.IdempotencyKeyTTL)).
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Order{}, false, nil
+	}
+	if err != nil {
+		return models.Order{}, false, err
+	}
 
+	var order models.Order
+	err = db.Scopes(withOrderProducts).Where("user_id = ?", userID).First(&order, record.OrderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Заказ удален — ключ больше ни на что не указывает
+		return models.Order{}, false, nil
+	}
+	if err != nil {
+		return models.Order{}, false, err
+	}
+	return order, true, nil
 }
 
 // GetUserOrders godoc
 // @Summary Получение списка заказов пользователя
-// @Description Возвращает список заказов, связанных с пользователем, включая информацию о продуктах в заказах
+// @Description Возвращает заказы пользователя с продуктами, с пагинацией и необязательной фильтрацией по дате создания (RFC3339, границы включительно)
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "Токен доступа пользователя (JWT)"
-// @Success 200 {array} models.Order "Список заказов с продуктами"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Success 200 {object} models.OrderResponse "Список заказов с продуктами"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
@@ -135,31 +395,67 @@ func CreateOrder(c *gin.Context) {
 func GetUserOrders(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	from, to, err := utils.ParseDateRange(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	query := requestDB(c).Model(&models.Order{}).Where("user_id = ?", userID)
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at <= ?", *to)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching orders")
 		return
 	}
 
 	var orders []models.Order
-	if err := services.DB.Preload("Products.Product").Where("user_id = ?", userID).Find(&orders).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error fetching orders")
+	if err := query.Scopes(withOrderProducts).
+		Order("created_at desc, id desc").
+		Limit(limitInt).
+		Offset((pageInt - 1) * limitInt).
+		Find(&orders).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching orders")
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	c.JSON(http.StatusOK, models.OrderResponse{
+		Data:       orders,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
+	})
 }
 
 // GetOrderByID godoc
 // @Summary Получение информации о заказе по идентификатору
-// @Description Возвращает данные заказа, включая связанные продукты, если заказ принадлежит авторизованному пользователю
+// @Description Возвращает данные заказа, если заказ принадлежит авторизованному пользователю.
+// @Description Позиции заказа с продуктами включаются только при expand=products; суммы заказа считаются в обоих случаях.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "Токен доступа пользователя (JWT)"
 // @Param id path int true "Идентификатор заказа"
-// @Success 200 {object} models.Order "Информация о заказе с продуктами"
+// @Param expand query string false "Связи для загрузки через запятую: products"
+// @Success 200 {object} models.Order "Информация о заказе"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
 // @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /orders/{id} [get]
 func GetOrderByID(c *gin.Context) {
@@ -167,33 +463,139 @@ func GetOrderByID(c *gin.Context) {
 	orderIDParam := c.Param("id")
 	orderID, err := strconv.Atoi(orderIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid order ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
 		return
 	}
 
 	// Получение идентификатора пользователя из контекста
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	expand, err := utils.ParseExpand(c, "products")
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
 		return
 	}
 
+	query := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID)
+	if expand["products"] {
+		query = query.Scopes(withOrderProducts)
+	}
+
 	var order models.Order
-	// Загрузка заказа с продуктами
-	if err := services.DB.Preload("Products.Product").
-		Where("id = ? AND user_id = ?", orderID, userID).
-		First(&order).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Order not found")
+	if err := query.First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
 		return
 	}
 
+	// Без позиций AfterFind не может посчитать суммы, поэтому считаем их запросом
+	if !expand["products"] {
+		if err := fillOrderTotals(requestDB(c), &order); err != nil {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error calculating order totals")
+			return
+		}
+	}
+
 	// Возврат информации о заказе
 	c.JSON(http.StatusOK, order)
 }
 
+// fillOrderTotals считает суммы заказа в базе, не загружая его позиции
+func fillOrderTotals(db *gorm.DB, order *models.Order) error {
+	var subtotal float64
+	if err := db.Model(&models.OrderProduct{}).
+		Select("COALESCE(SUM(order_products.quantity * "+orderLinePrice+"), 0)").
+		Joins("JOIN products ON products.id = order_products.product_id").
+		Joins("LEFT JOIN product_variants ON product_variants.id = order_products.variant_id").
+		Where("order_products.order_id = ?", order.ID).
+		Scan(&subtotal).Error; err != nil {
+		return err
+	}
+
+	order.Subtotal = subtotal
+	order.Discount = models.CalculateDiscount(order.Subtotal, order.DiscountType, order.DiscountValue)
+	order.Total = order.Subtotal - order.Discount
+	return nil
+}
+
+// GetOrderSummary godoc
+// @Summary Сводка по заказу
+// @Description Возвращает количество позиций, общее количество товаров, сумму заказа и расшифровку по каждой позиции
+// @Tags orders
+// @Produce json
+// @Param Authorization header string false "Токен доступа пользователя (JWT)"
+// @Param id path int true "Идентификатор заказа"
+// @Success 200 {object} models.OrderSummaryResponse "Сводка по заказу"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /orders/{id}/summary [get]
+func GetOrderSummary(c *gin.Context) {
+	orderIDParam := c.Param("id")
+	orderID, err := strconv.Atoi(orderIDParam)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var order models.Order
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	lines := []models.OrderLineSummary{}
+	if err := requestDB(c).Model(&models.OrderProduct{}).
+		Select("order_products.product_id, order_products.variant_id, products.name, "+orderLinePrice+" AS price, order_products.quantity").
+		Joins("JOIN products ON products.id = order_products.product_id").
+		Joins("LEFT JOIN product_variants ON product_variants.id = order_products.variant_id").
+		Where("order_products.order_id = ?", order.ID).
+		Order("order_products.product_id asc").
+		Scan(&lines).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error calculating order summary")
+		return
+	}
+
+	summary := models.OrderSummaryResponse{
+		OrderID:   order.ID,
+		ItemCount: len(lines),
+		Lines:     lines,
+	}
+	for i := range summary.Lines {
+		line := &summary.Lines[i]
+		line.LineTotal = line.Price * float64(line.Quantity)
+		summary.TotalQuantity += line.Quantity
+		summary.Subtotal += line.LineTotal
+	}
+	summary.Discount = models.CalculateDiscount(summary.Subtotal, order.DiscountType, order.DiscountValue)
+	summary.Total = summary.Subtotal - summary.Discount
+
+	c.JSON(http.StatusOK, summary)
+}
+
 // AddProductToOrder godoc
 // @Summary Добавление продукта в заказ
 // @Description Добавляет продукт в заказ текущего пользователя. Если продукт уже существует в заказе, его количество увеличивается.
+// @Description Можно указать variant_id варианта продукта; продукт входит в заказ не более чем с одним вариантом.
 // @Tags orders
 // @Accept json
 // @Produce json
@@ -204,6 +606,7 @@ func GetOrderByID(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
 // @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Недостаточно товара на складе или заказ уже оформлен"
 // @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
 // @Security BearerAuth
 // @Router /orders/{id}/products [post]
@@ -211,146 +614,527 @@ func AddProductToOrder(c *gin.Context) {
 	orderIDParam := c.Param("id")
 	orderID, err := strconv.Atoi(orderIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid order ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
 		return
 	}
 
 	var request models.ProductInOrder
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request data")
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	if request.Quantity < 1 {
-		utils.HandleError(c, http.StatusBadRequest, "Quantity must be greater then zero")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, fmt.Sprintf("Quantity for product %d must be greater than zero", request.ProductID))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	var order models.Order
-	if err := services.DB.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Order not found")
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	if order.Status != models.OrderStatusPending {
+		handleClosedOrder(c, order.Status)
+		return
+	}
+
+	var product models.Product
+	if err := requestDB(c).First(&product, request.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeProductNotFound, fmt.Sprintf("Product with ID %d not found", request.ProductID))
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product")
+		return
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
 		return
 	}
 
+	message := "Product added to order"
+
 	var orderProduct models.OrderProduct
-	if err := services.DB.Where("order_id = ? AND product_id = ?", order.ID, request.ProductID).First(&orderProduct).Error; err == nil {
+	if err := tx.Where("order_id = ? AND product_id = ?", order.ID, request.ProductID).First(&orderProduct).Error; err == nil {
 		// Если продукт найден, обновляем его количество
 		orderProduct.Quantity += request.Quantity
-		if err := services.DB.Save(&orderProduct).Error; err != nil {
-			utils.HandleError(c, http.StatusInternalServerError, "Error updating product quantity")
+		if !checkLineVariant(c, tx, &orderProduct, request.VariantID, false) {
+			tx.Rollback()
+			return
+		}
+		if err := tx.Save(&orderProduct).Error; err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating product quantity")
+			return
+		}
+		message = "Product quantity updated"
+	} else {
+		// Создаем новый OrderProduct
+		orderProduct = models.OrderProduct{
+			OrderID:   order.ID,
+			ProductID: request.ProductID,
+			Quantity:  request.Quantity,
+		}
+		if !checkLineVariant(c, tx, &orderProduct, request.VariantID, true) {
+			tx.Rollback()
+			return
+		}
+		price, err := currentLinePrice(tx, orderProduct.ProductID, orderProduct.VariantID)
+		if err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product price")
 			return
 		}
+		orderProduct.PriceAtPurchase = price
 
-		c.JSON(http.StatusOK, models.MessageResponse{
-			Message: "Product quantity updated",
-		})
+		if err := tx.Create(&orderProduct).Error; err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error adding product to order")
+			return
+		}
+	}
+
+	if err := services.SetReservation(tx, order.ID, request.ProductID, orderProduct.Quantity); err != nil {
+		tx.Rollback()
+		handleReservationError(c, err, request.ProductID)
 		return
 	}
 
-	// Создаем новый OrderProduct
-	orderProduct = models.OrderProduct{
-		OrderID:   order.ID,
-		ProductID: request.ProductID,
-		Quantity:  request.Quantity,
+	if !checkOrderLimits(c, tx, order.ID) {
+		tx.Rollback()
+		return
 	}
 
-	if err := services.DB.Create(&orderProduct).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error adding product to order")
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
 		return
 	}
 
 	c.JSON(http.StatusOK, models.MessageResponse{
-		Message: "Product added to order",
+		Message: message,
 	})
 }
 
-// UpdateProductQuantity godoc
-// @Summary Обновление количества продукта в заказе
-// @Description Обновляет количество указанного продукта в заказе текущего пользователя.
+// AddProductsToOrderBatch godoc
+// @Summary Добавление нескольких продуктов в заказ
+// @Description Добавляет в заказ текущего пользователя несколько продуктов за один запрос в одной транзакции.
+// @Description Для продуктов, уже присутствующих в заказе, количество увеличивается. При ошибке в любой позиции заказ не меняется.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "Токен пользователя"
 // @Param id path int true "ID заказа"
-// @Param product_id path int true "ID продукта"
-// @Param quantity body models.UpdateProductQuantityRequest true "Новое количество продукта"
-// @Success 200 {object} models.MessageResponse "Успешное обновление количества продукта"
-// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Param request body models.AddOrderProductsRequest true "Продукты для добавления в заказ"
+// @Success 200 {array} models.OrderProduct "Итоговые позиции заказа по добавленным продуктам"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос или продукт не найден"
 // @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
-// @Failure 404 {object} models.ErrorResponse "Продукт или заказ не найден"
+// @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Недостаточно товара на складе или заказ уже оформлен"
 // @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
 // @Security BearerAuth
-// @Router /orders/{id}/products/{product_id} [patch]
-func UpdateProductQuantity(c *gin.Context) {
+// @Router /orders/{id}/products/batch [post]
+func AddProductsToOrderBatch(c *gin.Context) {
 	orderIDParam := c.Param("id")
 	orderID, err := strconv.Atoi(orderIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid order ID")
-		return
-	}
-
-	productIDParam := c.Param("product_id")
-	productID, err := strconv.Atoi(productIDParam)
-	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid product ID")
-		return
-	}
-
-	var request models.UpdateProductQuantityRequest
-
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request data")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
 		return
 	}
 
-	if request.Quantity <= 0 {
-		utils.HandleError(c, http.StatusBadRequest, "Quantity must be greater than zero")
+	var request models.AddOrderProductsRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
-	// Получаем user_id из контекста
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Проверяем, принадлежит ли заказ пользователю
 	var order models.Order
-	if err := services.DB.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Order not found")
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
 		return
 	}
 
-	// Проверяем, существует ли продукт в заказе
-	var orderProduct models.OrderProduct
-	if err := services.DB.Where("order_id = ? AND product_id = ?", order.ID, productID).First(&orderProduct).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Product not found in the order")
+	if order.Status != models.OrderStatusPending {
+		handleClosedOrder(c, order.Status)
 		return
 	}
 
-	// Обновляем количество
-	orderProduct.Quantity = request.Quantity
-	if err := services.DB.Save(&orderProduct).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error updating product quantity")
-		return
+	// Повторяющиеся продукты в запросе складываются в одну позицию
+	quantities := map[int]int{}
+	variants := map[int]*int{}
+	var productIDs []int
+	for _, p := range request.Products {
+		if p.Quantity < 1 {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, fmt.Sprintf("Quantity for product %d must be greater than zero", p.ProductID))
+			return
+		}
+		if _, seen := quantities[p.ProductID]; !seen {
+			productIDs = append(productIDs, p.ProductID)
+		}
+		quantities[p.ProductID] += p.Quantity
+		if p.VariantID != nil {
+			if v := variants[p.ProductID]; v != nil && *v != *p.VariantID {
+				utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, fmt.Sprintf("Product %d is listed with different variants", p.ProductID))
+				return
+			}
+			variants[p.ProductID] = p.VariantID
+		}
 	}
 
-	c.JSON(http.StatusOK, models.MessageResponse{
-		Message: "Product quantity updated successfully",
-	})
-}
+	tx := requestDB(c).Begin()
 
-// DeleteProductFromOrder godoc
-// @Summary Удаление продукта из заказа
-// @Description Удаляет указанный продукт из заказа текущего пользователя.
-// @Tags orders
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	for _, productID := range productIDs {
+		var product models.Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.HandleError(c, http.StatusBadRequest, models.ErrCodeProductNotFound, fmt.Sprintf("Product with ID %d not found", productID))
+				return
+			}
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product")
+			return
+		}
+
+		var orderProduct models.OrderProduct
+		if err := tx.Where("order_id = ? AND product_id = ?", order.ID, productID).First(&orderProduct).Error; err == nil {
+			orderProduct.Quantity += quantities[productID]
+			if !checkLineVariant(c, tx, &orderProduct, variants[productID], false) {
+				tx.Rollback()
+				return
+			}
+			if err := tx.Save(&orderProduct).Error; err != nil {
+				tx.Rollback()
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating product quantity")
+				return
+			}
+		} else {
+			orderProduct = models.OrderProduct{
+				OrderID:   order.ID,
+				ProductID: productID,
+				Quantity:  quantities[productID],
+			}
+			if !checkLineVariant(c, tx, &orderProduct, variants[productID], true) {
+				tx.Rollback()
+				return
+			}
+			price, err := currentLinePrice(tx, orderProduct.ProductID, orderProduct.VariantID)
+			if err != nil {
+				tx.Rollback()
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product price")
+				return
+			}
+			orderProduct.PriceAtPurchase = price
+			if err := tx.Create(&orderProduct).Error; err != nil {
+				tx.Rollback()
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error adding product to order")
+				return
+			}
+		}
+
+		if err := services.SetReservation(tx, order.ID, productID, orderProduct.Quantity); err != nil {
+			tx.Rollback()
+			handleReservationError(c, err, productID)
+			return
+		}
+	}
+
+	if !checkOrderLimits(c, tx, order.ID) {
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+
+	var lines []models.OrderProduct
+	if err := requestDB(c).Preload("Product").
+		Where("order_id = ? AND product_id IN ?", order.ID, productIDs).
+		Order("product_id asc").
+		Find(&lines).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order products")
+		return
+	}
+
+	c.JSON(http.StatusOK, lines)
+}
+
+// UpdateProductQuantity godoc
+// @Summary Обновление количества продукта в заказе
+// @Description Обновляет количество указанного продукта в заказе текущего пользователя.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Токен пользователя"
+// @Param id path int true "ID заказа"
+// @Param product_id path int true "ID продукта"
+// @Param quantity body models.UpdateProductQuantityRequest true "Новое количество продукта"
+// @Success 200 {object} models.MessageResponse "Успешное обновление количества продукта"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Продукт или заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Недостаточно товара на складе или заказ уже оформлен"
+// @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
+// @Security BearerAuth
+// @Router /orders/{id}/products/{product_id} [patch]
+func UpdateProductQuantity(c *gin.Context) {
+	orderIDParam := c.Param("id")
+	orderID, err := strconv.Atoi(orderIDParam)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
+		return
+	}
+
+	productIDParam := c.Param("product_id")
+	productID, err := strconv.Atoi(productIDParam)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid product ID")
+		return
+	}
+
+	var request models.UpdateProductQuantityRequest
+
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	// Получаем user_id из контекста
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Проверяем, принадлежит ли заказ пользователю
+	var order models.Order
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	if order.Status != models.OrderStatusPending {
+		handleClosedOrder(c, order.Status)
+		return
+	}
+
+	// Проверяем, существует ли продукт в заказе
+	var orderProduct models.OrderProduct
+	if err := requestDB(c).Where("order_id = ? AND product_id = ?", order.ID, productID).First(&orderProduct).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderItemNotFound, "Product not found in the order")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order item")
+		return
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	// Обновляем количество
+	orderProduct.Quantity = request.Quantity
+	if !checkLineVariant(c, tx, &orderProduct, nil, false) {
+		tx.Rollback()
+		return
+	}
+	if err := tx.Save(&orderProduct).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating product quantity")
+		return
+	}
+
+	if err := services.SetReservation(tx, order.ID, productID, orderProduct.Quantity); err != nil {
+		tx.Rollback()
+		handleReservationError(c, err, productID)
+		return
+	}
+
+	if !checkOrderLimits(c, tx, order.ID) {
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "Product quantity updated successfully",
+	})
+}
+
+// UpdateOrderProductsBatch godoc
+// @Summary Обновление количества нескольких продуктов в заказе
+// @Description Устанавливает новое количество для нескольких позиций заказа текущего пользователя в одной транзакции.
+// @Description Если какого-либо продукта нет в заказе или количество меньше единицы, заказ не меняется.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Токен пользователя"
+// @Param id path int true "ID заказа"
+// @Param request body models.UpdateOrderProductsRequest true "Продукты и их новое количество"
+// @Success 200 {array} models.OrderProduct "Обновленные позиции заказа"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Заказ не найден или продукта нет в заказе"
+// @Failure 409 {object} models.ErrorResponse "Недостаточно товара на складе или заказ уже оформлен"
+// @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
+// @Security BearerAuth
+// @Router /orders/{id}/products [patch]
+func UpdateOrderProductsBatch(c *gin.Context) {
+	orderIDParam := c.Param("id")
+	orderID, err := strconv.Atoi(orderIDParam)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
+		return
+	}
+
+	var request models.UpdateOrderProductsRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var order models.Order
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	if order.Status != models.OrderStatusPending {
+		handleClosedOrder(c, order.Status)
+		return
+	}
+
+	// Новое количество задается явно, поэтому повтор продукта в запросе считается ошибкой
+	quantities := map[int]int{}
+	var productIDs []int
+	for _, p := range request.Products {
+		if p.Quantity < 1 {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, fmt.Sprintf("Quantity for product %d must be greater than zero", p.ProductID))
+			return
+		}
+		if _, seen := quantities[p.ProductID]; seen {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, fmt.Sprintf("Product %d is listed more than once", p.ProductID))
+			return
+		}
+		quantities[p.ProductID] = p.Quantity
+		productIDs = append(productIDs, p.ProductID)
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	for _, productID := range productIDs {
+		var orderProduct models.OrderProduct
+		if err := tx.Where("order_id = ? AND product_id = ?", order.ID, productID).First(&orderProduct).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderItemNotFound, fmt.Sprintf("Product %d not found in the order", productID))
+				return
+			}
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order item")
+			return
+		}
+
+		orderProduct.Quantity = quantities[productID]
+		if !checkLineVariant(c, tx, &orderProduct, nil, false) {
+			tx.Rollback()
+			return
+		}
+		if err := tx.Save(&orderProduct).Error; err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating product quantity")
+			return
+		}
+
+		if err := services.SetReservation(tx, order.ID, productID, orderProduct.Quantity); err != nil {
+			tx.Rollback()
+			handleReservationError(c, err, productID)
+			return
+		}
+	}
+
+	if !checkOrderLimits(c, tx, order.ID) {
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+
+	var lines []models.OrderProduct
+	if err := requestDB(c).Preload("Product").
+		Where("order_id = ? AND product_id IN ?", order.ID, productIDs).
+		Order("product_id asc").
+		Find(&lines).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order products")
+		return
+	}
+
+	c.JSON(http.StatusOK, lines)
+}
+
+// DeleteProductFromOrder godoc
+// @Summary Удаление продукта из заказа
+// @Description Удаляет указанный продукт из заказа текущего пользователя.
+// @Tags orders
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "Токен пользователя"
@@ -360,6 +1144,7 @@ func UpdateProductQuantity(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
 // @Failure 404 {object} models.ErrorResponse "Продукт или заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Заказ уже оформлен"
 // @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
 // @Security BearerAuth
 // @Router /orders/{id}/products/{product_id} [delete]
@@ -367,34 +1152,62 @@ func DeleteProductFromOrder(c *gin.Context) {
 	orderIDParam := c.Param("id")
 	orderID, err := strconv.Atoi(orderIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid order ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
 		return
 	}
 
 	productIDParam := c.Param("product_id")
 	productID, err := strconv.Atoi(productIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid product ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid product ID")
 		return
 	}
 
 	// Получаем user_id из контекста
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Проверяем, принадлежит ли заказ пользователю
 	var order models.Order
-	if err := services.DB.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Order not found")
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	if order.Status != models.OrderStatusPending {
+		handleClosedOrder(c, order.Status)
+		return
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	// Удаляем продукт из заказа и снимаем его резерв
+	if err := tx.Where("order_id = ? AND product_id = ?", order.ID, productID).Delete(&models.OrderProduct{}).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting product from order")
 		return
 	}
 
-	// Удаляем продукт из заказа
-	if err := services.DB.Where("order_id = ? AND product_id = ?", order.ID, productID).Delete(&models.OrderProduct{}).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting product from order")
+	if err := services.SetReservation(tx, order.ID, productID, 0); err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error releasing reserved stock")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
 		return
 	}
 
@@ -403,66 +1216,642 @@ func DeleteProductFromOrder(c *gin.Context) {
 	})
 }
 
-// DeleteOrder godoc
-// @Summary Удаление заказа
-// @Description Удаляет указанный заказ текущего пользователя вместе с привязанными продуктами.
+// ClearOrderProducts godoc
+// @Summary Очистка заказа
+// @Description Удаляет все продукты из заказа текущего пользователя, сохраняя сам заказ.
 // @Tags orders
-// @Accept json
 // @Produce json
 // @Param Authorization header string false "Токен пользователя"
 // @Param id path int true "ID заказа"
-// @Success 200 {object} models.MessageResponse "Успешное удаление заказа"
+// @Success 200 {object} models.RemovedItemsResponse "Количество удаленных позиций"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
 // @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Заказ уже оформлен"
 // @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
 // @Security BearerAuth
-// @Router /orders/{id} [delete]
-func DeleteOrder(c *gin.Context) {
+// @Router /orders/{id}/products [delete]
+func ClearOrderProducts(c *gin.Context) {
 	orderIDParam := c.Param("id")
 	orderID, err := strconv.Atoi(orderIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid order ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
 		return
 	}
 
-	// Получаем user_id из контекста
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Проверяем, принадлежит ли заказ пользователю
 	var order models.Order
-	if err := services.DB.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Order not found")
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
 		return
 	}
 
-	tx := services.DB.Begin()
+	if order.Status != models.OrderStatusPending {
+		handleClosedOrder(c, order.Status)
+		return
+	}
+
+	tx := requestDB(c).Begin()
 
 	if tx.Error != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error starting transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
 		return
 	}
 
-	// Удаление всех связанных продуктов
-	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderProduct{}).Error; err != nil {
+	result := tx.Where("order_id = ?", order.ID).Delete(&models.OrderProduct{})
+	if result.Error != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error clearing order products")
+		return
+	}
+
+	if err := services.ReleaseOrderReservations(tx, order.ID); err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error releasing reserved stock")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RemovedItemsResponse{
+		Message: "Order products cleared successfully",
+		Removed: result.RowsAffected,
+	})
+}
+
+// ApplyCoupon godoc
+// @Summary Применение купона к заказу
+// @Description Проверяет код купона, срок его действия и лимит использований и сохраняет скидку в заказе текущего пользователя.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Токен пользователя"
+// @Param id path int true "ID заказа"
+// @Param request body models.ApplyCouponRequest true "Код купона"
+// @Success 200 {object} models.Order "Заказ с примененной скидкой"
+// @Failure 400 {object} models.ErrorResponse "Купон недействителен, истек или исчерпан"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Заказ уже оформлен"
+// @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
+// @Security BearerAuth
+// @Router /orders/{id}/apply-coupon [post]
+func ApplyCoupon(c *gin.Context) {
+	orderIDParam := c.Param("id")
+	orderID, err := strconv.Atoi(orderIDParam)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
+		return
+	}
+
+	var request models.ApplyCouponRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var order models.Order
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	if order.Status != models.OrderStatusPending {
+		handleClosedOrder(c, order.Status)
+		return
+	}
+
+	if order.CouponCode != "" {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCouponApplied, "Coupon already applied to this order")
+		return
+	}
+
+	var coupon models.Coupon
+	if err := requestDB(c).Where("code = ?", strings.ToUpper(strings.TrimSpace(request.Code))).First(&coupon).Error; err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCouponInvalid, "Invalid coupon code")
+		return
+	}
+
+	if coupon.ExpiresAt != nil && time.Now().After(*coupon.ExpiresAt) {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCouponExpired, "Coupon has expired")
+		return
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	// Увеличиваем счетчик атомарно, чтобы параллельные запросы не превысили лимит
+	result := tx.Model(&models.Coupon{}).
+		Where("id = ? AND (usage_limit = 0 OR used_count < usage_limit)", coupon.ID).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error applying coupon")
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCouponExhausted, "Coupon usage limit reached")
+		return
+	}
+
+	// Заказ обновляется условно: параллельный запрос мог уже применить купон или оформить заказ,
+	// тогда откат транзакции возвращает и счетчик использований купона
+	applied := tx.Model(&models.Order{}).
+		Where("id = ? AND status = ? AND COALESCE(coupon_code, '') = ''", order.ID, models.OrderStatusPending).
+		Updates(map[string]interface{}{
+			"coupon_code":    coupon.Code,
+			"discount_type":  coupon.Type,
+			"discount_value": coupon.Value,
+		})
+	if applied.Error != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error applying coupon")
+		return
+	}
+	if applied.RowsAffected == 0 {
+		tx.Rollback()
+		var current models.Order
+		if err := requestDB(c).Select("status").First(&current, order.ID).Error; err != nil {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+			return
+		}
+		if current.Status != models.OrderStatusPending {
+			handleClosedOrder(c, current.Status)
+			return
+		}
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCouponApplied, "Coupon already applied to this order")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+
+	if err := requestDB(c).Scopes(withOrderProducts).First(&order, order.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CheckoutOrder godoc
+// @Summary Оформление заказа
+// @Description Оформляет заказ текущего пользователя: зарезервированные товары списываются со склада, а заказ переходит в статус completed.
+// @Description Если срок резерва истек, товары резервируются повторно при наличии на складе.
+// @Description Для позиций с вариантом продукта остаток варианта уменьшается на количество в позиции. Остаток варианта проверяется повторно под блокировкой; если его не хватает или вариант удален, возвращается 409.
+// @Tags orders
+// @Produce json
+// @Param Authorization header string false "Токен пользователя"
+// @Param id path int true "ID заказа"
+// @Success 200 {object} models.Order "Оформленный заказ"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос или пустой заказ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Недостаточно товара на складе или заказ уже оформлен"
+// @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
+// @Security BearerAuth
+// @Router /orders/{id}/checkout [post]
+func CheckoutOrder(c *gin.Context) {
+	orderIDParam := c.Param("id")
+	orderID, err := strconv.Atoi(orderIDParam)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var order models.Order
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	if order.Status != models.OrderStatusPending {
+		handleClosedOrder(c, order.Status)
+		return
+	}
+
+	// Позиции упорядочены по продукту, чтобы параллельные оформления блокировали строки в одном порядке
+	var lines []models.OrderProduct
+	if err := requestDB(c).Where("order_id = ?", order.ID).Order("product_id asc").Find(&lines).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order products")
+		return
+	}
+
+	if len(lines) == 0 {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Order is empty")
+		return
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	// Статус меняется условно, чтобы параллельное оформление не списало товар дважды
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND status = ?", order.ID, models.OrderStatusPending).
+		Update("status", models.OrderStatusCompleted)
+	if result.Error != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error checking out order")
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeOrderCheckedOut, "Order has already been checked out")
+		return
+	}
+
+	// Подтверждаем резерв каждой позиции: просроченный резерв будет взят заново
+	for _, line := range lines {
+		if err := services.SetReservation(tx, order.ID, line.ProductID, line.Quantity); err != nil {
+			tx.Rollback()
+			handleReservationError(c, err, line.ProductID)
+			return
+		}
+	}
+
+	for _, line := range lines {
+		if line.VariantID == nil {
+			continue
+		}
+		if err := services.DeductVariantStock(tx, *line.VariantID, line.Quantity); err != nil {
+			tx.Rollback()
+			handleVariantStockError(c, err, *line.VariantID)
+			return
+		}
+	}
+
+	if err := services.CommitOrderReservations(tx, order.ID); err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error checking out order")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+
+	if err := requestDB(c).Scopes(withOrderProducts).First(&order, order.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ReorderOrder godoc
+// @Summary Повтор заказа
+// @Description Создает новый заказ текущего пользователя с теми же позициями и количествами, что и в указанном заказе.
+// @Description Продукты, которые больше не существуют, пропускаются и перечисляются в ответе.
+// @Tags orders
+// @Produce json
+// @Param Authorization header string false "Токен пользователя"
+// @Param id path int true "ID исходного заказа"
+// @Success 201 {object} models.ReorderResponse "Новый заказ и пропущенные продукты"
+// @Header 201 {string} Location "Адрес созданного заказа"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Недостаточно товара на складе"
+// @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
+// @Security BearerAuth
+// @Router /orders/{id}/reorder [post]
+func ReorderOrder(c *gin.Context) {
+	orderIDParam := c.Param("id")
+	orderID, err := strconv.Atoi(orderIDParam)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var source models.Order
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	var lines []models.OrderProduct
+	if err := requestDB(c).Where("order_id = ?", source.ID).Order("product_id asc").Find(&lines).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order products")
+		return
+	}
+
+	order := models.Order{
+		UserID: source.UserID,
+		Status: models.OrderStatusPending,
+	}
+	skipped := []int{}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating order")
+		return
+	}
+
+	for _, line := range lines {
+		// Удаленные продукты в новый заказ не переносятся
+		var product models.Product
+		if err := tx.First(&product, line.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				skipped = append(skipped, line.ProductID)
+				continue
+			}
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product")
+			return
+		}
+
+		// Как и удаленные продукты, удаленные варианты не переносятся
+		if line.VariantID != nil {
+			if err := tx.First(&models.ProductVariant{}, *line.VariantID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					skipped = append(skipped, line.ProductID)
+					continue
+				}
+				tx.Rollback()
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product variant")
+				return
+			}
+		}
+
+		// Новый заказ покупается по текущей цене, а не по цене исходного заказа
+		price, err := currentLinePrice(tx, line.ProductID, line.VariantID)
+		if err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product price")
+			return
+		}
+
+		if err := tx.Create(&models.OrderProduct{
+			OrderID:         order.ID,
+			ProductID:       line.ProductID,
+			Quantity:        line.Quantity,
+			VariantID:       line.VariantID,
+			PriceAtPurchase: price,
+		}).Error; err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating order product")
+			return
+		}
+
+		if err := services.SetReservation(tx, order.ID, line.ProductID, line.Quantity); err != nil {
+			tx.Rollback()
+			handleReservationError(c, err, line.ProductID)
+			return
+		}
+	}
+
+	if !checkOrderLimits(c, tx, order.ID) {
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+
+	if err := requestDB(c).Scopes(withOrderProducts).First(&order, order.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching created order")
+		return
+	}
+
+	services.NotifyOrderPlaced(order)
+
+	c.Header("Location", fmt.Sprintf("/orders/%d", order.ID))
+	c.JSON(http.StatusCreated, models.ReorderResponse{
+		Order:           order,
+		SkippedProducts: skipped,
+	})
+}
+
+// CancelOrder godoc
+// @Summary Отмена заказа
+// @Description Переводит заказ текущего пользователя в статус cancelled. Заказ и позиции сохраняются в истории.
+// @Description У неоформленного заказа снимается резерв позиций, у оформленного списанные товары возвращаются на склад, в том числе остатки вариантов.
+// @Description Уже отмененный заказ отменить нельзя.
+// @Tags orders
+// @Produce json
+// @Param Authorization header string false "Токен пользователя"
+// @Param id path int true "Идентификатор заказа"
+// @Success 200 {object} models.Order "Отмененный заказ"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} models.ErrorResponse "Заказ уже отменен"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /orders/{id}/cancel [post]
+func CancelOrder(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var order models.Order
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	if order.Status != models.OrderStatusPending && order.Status != models.OrderStatusCompleted {
+		handleClosedOrder(c, order.Status)
+		return
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	// Статус меняется условно, чтобы отмена не пересеклась с параллельным оформлением или отменой
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND status = ?", order.ID, order.Status).
+		Update("status", models.OrderStatusCancelled)
+	if result.Error != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error cancelling order")
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		var current models.Order
+		if err := requestDB(c).Select("status").First(&current, order.ID).Error; err != nil {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+			return
+		}
+		handleClosedOrder(c, current.Status)
+		return
+	}
+
+	if order.Status == models.OrderStatusPending {
+		if err := services.ReleaseOrderReservations(tx, order.ID); err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error releasing reserved stock")
+			return
+		}
+	} else if err := services.RestoreOrderStock(tx, order.ID); err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error restoring stock")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+
+	if err := requestDB(c).Scopes(withOrderProducts).First(&order, order.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// DeleteOrder godoc
+// @Summary Удаление заказа
+// @Description Удаляет указанный заказ текущего пользователя вместе с привязанными продуктами.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Токен пользователя"
+// @Param id path int true "ID заказа"
+// @Success 200 {object} models.MessageResponse "Успешное удаление заказа"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Заказ не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка на сервере"
+// @Security BearerAuth
+// @Router /orders/{id} [delete]
+func DeleteOrder(c *gin.Context) {
+	orderIDParam := c.Param("id")
+	orderID, err := strconv.Atoi(orderIDParam)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
+		return
+	}
+
+	// Получаем user_id из контекста
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Проверяем, принадлежит ли заказ пользователю
+	var order models.Order
+	if err := requestDB(c).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
+		return
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	// Удаление всех связанных продуктов
+	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderProduct{}).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting order products")
+		return
+	}
+
+	if err := services.ReleaseOrderReservations(tx, order.ID); err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting order products")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error releasing reserved stock")
 		return
 	}
 
 	// Удаление самого заказа
 	if err := tx.Delete(&order).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting order")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting order")
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error committing transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
 		return
 	}
 
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"project/models"
+	"project/services"
+	"testing"
+)
+
+func TestOrderKeepsPriceAtPurchase(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+
+	recorder := createOrderRequest(t, user, models.ProductInOrder{ProductID: product.ID, Quantity: 2})
+	assertStatus(t, recorder, http.StatusCreated)
+	var created models.Order
+	decodeBody(t, recorder, &created)
+
+	services.DB.Model(&models.Product{}).Where("id = ?", product.ID).Update("price", 25)
+
+	var line models.OrderProduct
+	services.DB.Where("order_id = ? AND product_id = ?", created.ID, product.ID).First(&line)
+	if line.PriceAtPurchase != 10 {
+		t.Fatalf("stored line price = %v, want 10", line.PriceAtPurchase)
+	}
+
+	for _, query := range []string{"", "?expand=products"} {
+		recorder := perform(t, GetOrderByID, testRequest{
+			method: http.MethodGet, route: "/orders/:id", target: fmt.Sprintf("/orders/%d%s", created.ID, query), user: &user,
+		})
+		assertStatus(t, recorder, http.StatusOK)
+		var order models.Order
+		decodeBody(t, recorder, &order)
+		if order.Subtotal != 20 || order.Total != 20 {
+			t.Fatalf("GET /orders/%d%s subtotal/total = %v/%v, want 20/20", created.ID, query, order.Subtotal, order.Total)
+		}
+		for _, line := range order.Products {
+			if line.PriceAtPurchase != 10 {
+				t.Fatalf("line price_at_purchase = %v, want 10", line.PriceAtPurchase)
+			}
+		}
+	}
+
+	recorder = perform(t, GetOrderSummary, testRequest{
+		method: http.MethodGet, route: "/orders/:id/summary", target: fmt.Sprintf("/orders/%d/summary", created.ID), user: &user,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	var summary models.OrderSummaryResponse
+	decodeBody(t, recorder, &summary)
+	if len(summary.Lines) != 1 || summary.Lines[0].Price != 10 || summary.Total != 20 {
+		t.Fatalf("summary = %+v, want one line at 10 and total 20", summary)
+	}
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"project/services"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func createVariant(t *testing.T, product models.Product, stock int) models.ProductVariant {
+	t.Helper()
+	variant := models.ProductVariant{ProductID: product.ID, Attributes: models.StringMap{"flavor": "vanilla"}, Stock: stock}
+	if err := services.DB.Create(&variant).Error; err != nil {
+		t.Fatalf("create variant: %v", err)
+	}
+	return variant
+}
+
+func reserve(t *testing.T, order models.Order, product models.Product, quantity int) {
+	t.Helper()
+	if err := services.SetReservation(services.DB, order.ID, product.ID, quantity); err != nil {
+		t.Fatalf("reserve product %d: %v", product.ID, err)
+	}
+}
+
+func orderAction(t *testing.T, handler gin.HandlerFunc, action string, user models.User, order models.Order) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, handler, testRequest{
+		method: http.MethodPost,
+		route:  "/orders/:id/" + action,
+		target: fmt.Sprintf("/orders/%d/%s", order.ID, action),
+		user:   &user,
+	})
+}
+
+func reloadOrderStatus(t *testing.T, id int) string {
+	t.Helper()
+	var order models.Order
+	if err := services.DB.Select("status").First(&order, id).Error; err != nil {
+		t.Fatalf("reload order %d: %v", id, err)
+	}
+	return order.Status
+}
+
+func TestCancelPendingOrderReleasesReservation(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	order := createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: product.ID, Quantity: 2, PriceAtPurchase: 10})
+	reserve(t, order, product, 2)
+
+	recorder := orderAction(t, CancelOrder, "cancel", user, order)
+	assertStatus(t, recorder, http.StatusOK)
+
+	if status := reloadOrderStatus(t, order.ID); status != models.OrderStatusCancelled {
+		t.Fatalf("status = %s, want %s", status, models.OrderStatusCancelled)
+	}
+	if got := reloadProduct(t, product.ID); got.Stock != 5 || got.Reserved != 0 {
+		t.Fatalf("stock/reserved = %d/%d, want 5/0", got.Stock, got.Reserved)
+	}
+	var lines int64
+	services.DB.Model(&models.OrderProduct{}).Where("order_id = ?", order.ID).Count(&lines)
+	if lines != 1 {
+		t.Fatalf("order has %d lines after cancel, want 1", lines)
+	}
+}
+
+func TestCancelCompletedOrderRestoresStock(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	variant := createVariant(t, product, 3)
+	order := createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: product.ID, VariantID: &variant.ID, Quantity: 2, PriceAtPurchase: 10})
+	reserve(t, order, product, 2)
+
+	assertStatus(t, orderAction(t, CheckoutOrder, "checkout", user, order), http.StatusOK)
+	if got := reloadProduct(t, product.ID); got.Stock != 3 {
+		t.Fatalf("stock after checkout = %d, want 3", got.Stock)
+	}
+
+	recorder := orderAction(t, CancelOrder, "cancel", user, order)
+	assertStatus(t, recorder, http.StatusOK)
+
+	if status := reloadOrderStatus(t, order.ID); status != models.OrderStatusCancelled {
+		t.Fatalf("status = %s, want %s", status, models.OrderStatusCancelled)
+	}
+	if got := reloadProduct(t, product.ID); got.Stock != 5 || got.Reserved != 0 {
+		t.Fatalf("stock/reserved = %d/%d, want 5/0", got.Stock, got.Reserved)
+	}
+	var restored models.ProductVariant
+	services.DB.First(&restored, variant.ID)
+	if restored.Stock != 3 {
+		t.Fatalf("variant stock = %d, want 3", restored.Stock)
+	}
+}
+
+func TestCancelOrderRejectsCancelledOrder(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	order := createOrder(t, user, models.OrderStatusCancelled,
+		models.OrderProduct{ProductID: product.ID, Quantity: 2, PriceAtPurchase: 10})
+
+	recorder := orderAction(t, CancelOrder, "cancel", user, order)
+	assertErrorCode(t, recorder, http.StatusConflict, models.ErrCodeOrderCancelled)
+	if got := reloadProduct(t, product.ID); got.Stock != 5 {
+		t.Fatalf("stock = %d, want 5", got.Stock)
+	}
+}
+
+func TestCancelOrderOfAnotherUser(t *testing.T) {
+	setupDB(t)
+	owner := createUser(t, models.RoleUser)
+	other := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	order := createOrder(t, owner, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: product.ID, Quantity: 2, PriceAtPurchase: 10})
+
+	recorder := orderAction(t, CancelOrder, "cancel", other, order)
+	assertErrorCode(t, recorder, http.StatusNotFound, models.ErrCodeOrderNotFound)
+	if status := reloadOrderStatus(t, order.ID); status != models.OrderStatusCompleted {
+		t.Fatalf("status = %s, want %s", status, models.OrderStatusCompleted)
+	}
+}
+
+func TestApplyCouponConcurrently(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 100, 5)
+	order := createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: product.ID, Quantity: 1, PriceAtPurchase: 100})
+	coupons := []models.Coupon{
+		{Code: "TEN", Type: models.CouponTypePercent, Value: 10},
+		{Code: "FIVE", Type: models.CouponTypeFixed, Value: 5},
+	}
+	for i := range coupons {
+		if err := services.DB.Create(&coupons[i]).Error; err != nil {
+			t.Fatalf("create coupon: %v", err)
+		}
+	}
+
+	recorders := make([]*httptest.ResponseRecorder, len(coupons))
+	var wg sync.WaitGroup
+	for i := range coupons {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorders[i] = perform(t, ApplyCoupon, testRequest{
+				method: http.MethodPost,
+				route:  "/orders/:id/apply-coupon",
+				target: fmt.Sprintf("/orders/%d/apply-coupon", order.ID),
+				user:   &user,
+				body:   models.ApplyCouponRequest{Code: coupons[i].Code},
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	applied := ""
+	for i, recorder := range recorders {
+		if recorder.Code == http.StatusOK {
+			if applied != "" {
+				t.Fatalf("both coupons applied")
+			}
+			applied = coupons[i].Code
+			continue
+		}
+		assertErrorCode(t, recorder, http.StatusBadRequest, models.ErrCodeCouponApplied)
+	}
+	if applied == "" {
+		t.Fatal("no coupon applied")
+	}
+
+	var saved models.Order
+	services.DB.First(&saved, order.ID)
+	if saved.CouponCode != applied {
+		t.Fatalf("order coupon = %q, want %q", saved.CouponCode, applied)
+	}
+	var used int64
+	services.DB.Model(&models.Coupon{}).Select("COALESCE(SUM(used_count), 0)").Scan(&used)
+	if used != 1 {
+		t.Fatalf("coupons used %d times, want 1", used)
+	}
+}
+
+func TestOrderLookupErrorsAreNotReportedAsNotFound(t *testing.T) {
+	useUnreachableDB(t)
+	user := models.User{ID: 1, Role: models.RoleUser}
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		req     testRequest
+	}{
+		{name: "get order", handler: GetOrderByID, req: testRequest{method: http.MethodGet, route: "/orders/:id", target: "/orders/1", user: &user}},
+		{name: "checkout", handler: CheckoutOrder, req: testRequest{method: http.MethodPost, route: "/orders/:id/checkout", target: "/orders/1/checkout", user: &user}},
+		{name: "add product", handler: AddProductToOrder, req: testRequest{
+			method: http.MethodPost, route: "/orders/:id/products", target: "/orders/1/products", user: &user,
+			body: models.ProductInOrder{ProductID: 1, Quantity: 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrorCode(t, perform(t, tt.handler, tt.req), http.StatusInternalServerError, models.ErrCodeInternal)
+		})
+	}
+}
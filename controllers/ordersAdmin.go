@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"project/models"
 	"project/services"
@@ -8,6 +10,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetAllOrders godoc
@@ -24,7 +27,7 @@ import (
 // @Param user_id query string false "ID пользователя"
 // @Param order_id query string false "ID заказа"
 // @Success 200 {array} models.OrderResponse "Список заказов с продуктами"
-// @Failuer 400 {object} models.ErrorResponse "Некорректные данные"
+// @Failure 400 {object} models.ErrorResponse "Некорректные параметры пагинации"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /admin/orders [get]
@@ -32,24 +35,19 @@ func GetAllOrders(c *gin.Context) {
 	var orders []models.Order
 	var total int64
 
-	page := c.DefaultQuery("page", "1")
-	limit := c.DefaultQuery("limit", "10")
 	sort := c.DefaultQuery("sort", "id")
 	order := c.DefaultQuery("order", "asc")
 	user_id := c.Query("user_id")
 	order_id := c.Query("order_id")
 
-	pageInt, err := strconv.Atoi(page)
+	pageInt, limitInt, err := utils.ParsePagination(c)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Incorrect page number")
-	}
-	limitInt, err := strconv.Atoi(limit)
-	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Incorrect limit")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
 	}
 	offset := (pageInt - 1) * limitInt
 
-	query := services.DB.Model(&models.Order{})
+	query := requestDB(c).Model(&models.Order{})
 
 	if user_id != "" {
 		query = query.Where("user_id = ?", user_id)
@@ -58,23 +56,87 @@ func GetAllOrders(c *gin.Context) {
 		query = query.Where("id = ?", order_id)
 	}
 
-	query.Count(&total)
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching orders")
+		return
+	}
 
 	if order != "asc" && order != "desc" {
 		order = "asc"
 	}
-	query = query.Order(sort + " " + order).Limit(limitInt).Offset(offset)
+	// Одинаковые значения упорядочиваем по id, чтобы страницы не пересекались
+	query = query.Order(sort + " " + order + ", id asc").Limit(limitInt).Offset(offset)
 
-	if err := query.Preload("Products.Product").Find(&orders).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error fetching orders")
+	if err := query.Scopes(withOrderProducts).Find(&orders).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching orders")
 		return
 	}
 
 	c.JSON(http.StatusOK, models.OrderResponse{
-		Data:  orders,
-		Total: total,
-		Page:  pageInt,
-		Limit: limitInt,
+		Data:       orders,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
+	})
+}
+
+// GetUserOrdersAdmin godoc
+// @Summary Получение заказов пользователя администратором
+// @Description Возвращает заказы указанного пользователя с продуктами, с пагинацией
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Токен доступа пользователя (JWT)"
+// @Param id path int true "ID пользователя"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Success 200 {object} models.OrderResponse "Список заказов пользователя с продуктами"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/orders [get]
+func GetUserOrdersAdmin(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid user ID")
+		return
+	}
+
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	var user models.User
+	if err := requestDB(c).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
+		}
+		return
+	}
+
+	var total int64
+	if err := requestDB(c).Model(&models.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching orders")
+		return
+	}
+
+	var orders []models.Order
+	if err := requestDB(c).Scopes(withOrderProducts).
+		Where("user_id = ?", userID).
+		Order("created_at desc, id desc").
+		Limit(limitInt).
+		Offset((pageInt - 1) * limitInt).
+		Find(&orders).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching orders")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OrderResponse{
+		Data:       orders,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
 	})
 }
 
@@ -97,42 +159,55 @@ func DeleteOrderAdmin(c *gin.Context) {
 	orderIDParam := c.Param("id")
 	orderID, err := strconv.Atoi(orderIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid order ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid order ID")
 		return
 	}
 
 	var order models.Order
-	if err := services.DB.Where("id = ?", orderID).First(&order).Error; err != nil {
-
-		utils.HandleError(c, http.StatusNotFound, "Order not found")
+	if err := requestDB(c).Where("id = ?", orderID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching order")
 		return
 	}
 
-	tx := services.DB.Begin()
+	tx := requestDB(c).Begin()
 
 	if tx.Error != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error starting transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
 		return
 	}
 
 	// Удаление всех связанных продуктов
 	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderProduct{}).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting order products")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting order products")
+		return
+	}
+
+	if err := services.ReleaseOrderReservations(tx, order.ID); err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error releasing reserved stock")
 		return
 	}
 
 	// Удаление самого заказа
-	if err := services.DB.Delete(&order).Error; err != nil {
+	if err := tx.Delete(&order).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting order")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting order")
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error committing transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
 		return
 	}
+	recordAudit(c, models.AuditActionOrderDelete, fmt.Sprintf("order:%d", order.ID), models.StringMap{
+		"user_id": strconv.Itoa(order.UserID),
+		"status":  order.Status,
+	})
 
 	c.JSON(http.StatusOK, models.MessageResponse{
 		Message: "Order deleted successfully",
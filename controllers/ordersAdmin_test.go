@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"project/models"
+	"project/services"
+	"testing"
+)
+
+// failCommitOnOrderDelete добавляет отложенный триггер: удаление заказа проходит,
+// но транзакция падает при фиксации
+func failCommitOnOrderDelete(t *testing.T) {
+	t.Helper()
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION test_fail_commit() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'simulated commit failure';
+		END $$ LANGUAGE plpgsql`,
+		`CREATE CONSTRAINT TRIGGER test_fail_commit AFTER DELETE ON orders
+		DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE FUNCTION test_fail_commit()`,
+	}
+	for _, statement := range statements {
+		if err := services.DB.Exec(statement).Error; err != nil {
+			t.Fatalf("install commit failure trigger: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		services.DB.Exec("DROP TRIGGER IF EXISTS test_fail_commit ON orders")
+		services.DB.Exec("DROP FUNCTION IF EXISTS test_fail_commit()")
+	})
+}
+
+func deleteOrderAdmin(t *testing.T, admin models.User, order models.Order) int {
+	t.Helper()
+	return perform(t, DeleteOrderAdmin, testRequest{
+		method: http.MethodDelete, route: "/admin/orders/:id", target: fmt.Sprintf("/admin/orders/%d", order.ID), user: &admin,
+	}).Code
+}
+
+func TestDeleteOrderAdminKeepsOrderWhenCommitFails(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	order := createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: product.ID, Quantity: 2, PriceAtPurchase: 10})
+	reserve(t, order, product, 2)
+	failCommitOnOrderDelete(t)
+
+	if code := deleteOrderAdmin(t, admin, order); code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", code, http.StatusInternalServerError)
+	}
+
+	var orders, lines, entries int64
+	services.DB.Model(&models.Order{}).Where("id = ?", order.ID).Count(&orders)
+	services.DB.Model(&models.OrderProduct{}).Where("order_id = ?", order.ID).Count(&lines)
+	services.DB.Model(&models.AuditLog{}).Count(&entries)
+	if orders != 1 || lines != 1 {
+		t.Fatalf("order rows/lines = %d/%d after failed commit, want 1/1", orders, lines)
+	}
+	if got := reloadProduct(t, product.ID); got.Reserved != 2 {
+		t.Fatalf("reserved = %d after failed commit, want 2", got.Reserved)
+	}
+	if entries != 0 {
+		t.Fatalf("%d audit entries after failed commit, want 0", entries)
+	}
+}
+
+func TestDeleteOrderAdmin(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	order := createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: product.ID, Quantity: 2, PriceAtPurchase: 10})
+	reserve(t, order, product, 2)
+
+	if code := deleteOrderAdmin(t, admin, order); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+
+	var orders int64
+	services.DB.Model(&models.Order{}).Where("id = ?", order.ID).Count(&orders)
+	if orders != 0 {
+		t.Fatal("order was not deleted")
+	}
+	if got := reloadProduct(t, product.ID); got.Reserved != 0 {
+		t.Fatalf("reserved = %d, want 0", got.Reserved)
+	}
+	assertAudit(t, admin, models.AuditActionOrderDelete, fmt.Sprintf("order:%d", order.ID))
+}
@@ -2,29 +2,37 @@ package controllers
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"project/models"
 	"project/services"
 	"project/utils"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetProductsByPriceRange godoc
 // @Summary Получение продуктов по диапазону цен
-// @Description Возвращает список продуктов, цены которых находятся в заданном диапазоне
+// @Description Возвращает постраничный список продуктов, цены которых находятся в заданном диапазоне. Если таких продуктов нет, возвращается пустой список
 // @Tags products
 // @Accept  json
 // @Produce  json
 // @Param        Authorization header string false "токен"
 // @Param        minPrice query number true "Минимальная цена"
 // @Param        maxPrice query number true "Максимальная цена"
-// @Success 200 {array} models.Product "Список продуктов в заданном диапазоне цен"
-// @Failure 400 {object} models.ErrorResponse "Некорректные значения цен"
-// @Failure 404 {object} models.ErrorResponse "Продукты не найдены в указанном диапазоне"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Param sort query string false "Поле для сортировки: id, name, price, manufacturer, category_id, stock, rating, review_count" default(id)
+// @Param order query string false "Направление сортировки" default(asc)
+// @Success 200 {object} models.ProductResponse "Продукты в заданном диапазоне цен"
+// @Failure 400 {object} models.ErrorResponse "Некорректные значения цен (в том числе minPrice больше maxPrice) или параметров запроса"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /products/price-range [get]
@@ -33,23 +41,53 @@ func GetProductsByPriceRange(c *gin.Context) {
 	maxPrice, err2 := strconv.ParseFloat(c.Query("maxPrice"), 64)
 
 	if err1 != nil || err2 != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid price range values")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid price range values")
+		return
+	}
+	if minPrice > maxPrice {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "minPrice must not be greater than maxPrice")
 		return
 	}
 
-	var products []models.Product
-	if err := services.DB.Where("price BETWEEN ? AND ?", minPrice, maxPrice).Find(&products).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error fetching products")
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
-	if len(products) == 0 {
-		utils.HandleError(c, http.StatusNotFound, "No products found in the given price range")
+	sortColumn, ok := productSortColumns[c.DefaultQuery("sort", "id")]
+	if !ok {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid sort field")
 		return
 	}
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		order = "asc"
+	}
 
-	c.JSON(http.StatusOK, products)
+	query := requestDB(c).Model(&models.Product{}).Where("products.price BETWEEN ? AND ?", minPrice, maxPrice)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching products")
+		return
+	}
 
+	products := []models.ProductSummary{}
+	if err := query.
+		Select(productSummaryColumns).
+		Order(sortColumn + " " + order + ", products.id asc").
+		Limit(limitInt).
+		Offset((pageInt - 1) * limitInt).
+		Find(&products).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching products")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProductResponse{
+		Data:       products,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
+	})
 }
 
 // UpdateProductsManufacturer godoc
@@ -69,26 +107,30 @@ func UpdateProductsManufacturer(c *gin.Context) {
 	manufacturer := c.Query("manufacturer")
 
 	if manufacturer == "" {
-		utils.HandleError(c, http.StatusBadRequest, "manufacturer query parameter is required")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "manufacturer query parameter is required")
 		return
 	}
 
 	// начало транзакции
-	tx := services.DB.Begin()
+	tx := requestDB(c).Begin()
 
 	// проверяем, что транзакция инициализирована корректно
 	if tx.Error != nil {
 		log.Println("Error starting transaction:", tx.Error)
-		utils.HandleError(c, http.StatusInternalServerError, "Failed to start transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start transaction")
 		return
 	}
 	log.Println("Transaction started successfully.")
 
 	// попытка массового обновления
-	if err := tx.Model(&models.Product{}).Where("1 = 1").Update("manufacturer", manufacturer).Error; err != nil {
+	result := tx.Model(&models.Product{}).Where("1 = 1").Updates(map[string]interface{}{
+		"manufacturer": manufacturer,
+		"version":      gorm.Expr("version + 1"),
+	})
+	if err := result.Error; err != nil {
 		tx.Rollback() // откатываем изменения при ошибке
 		log.Println("Error during update operation:", err)
-		utils.HandleError(c, http.StatusInternalServerError, "Error updating manufacturer: "+err.Error())
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating manufacturer: "+err.Error())
 		return
 	}
 	log.Println("Manufacturer update operation successful.")
@@ -96,16 +138,81 @@ func UpdateProductsManufacturer(c *gin.Context) {
 	// коммит транзакции
 	if err := tx.Commit().Error; err != nil {
 		log.Println("Error committing transaction:", err)
-		utils.HandleError(c, http.StatusInternalServerError, "Transaction commit failed: "+err.Error())
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Transaction commit failed: "+err.Error())
 		return
 	}
 	log.Println("Transaction committed successfully.")
+	recordAudit(c, models.AuditActionProductsManufacturerSet, "products:all", models.StringMap{
+		"manufacturer": manufacturer,
+		"updated":      strconv.FormatInt(result.RowsAffected, 10),
+	})
 
 	c.JSON(http.StatusOK, models.MessageResponse{
 		Message: "Manufacturer updated successfully",
 	})
 }
 
+// UpdateProductsCategory godoc
+// @Summary Массовый перенос продуктов в категорию
+// @Description Переносит перечисленные продукты в категорию category_id в одной транзакции и возвращает число обновленных продуктов. Несуществующие ID продуктов пропускаются.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param request body models.UpdateProductsCategoryRequest true "ID продуктов и новая категория"
+// @Success 200 {object} models.UpdateProductsCategoryResponse "Число обновленных продуктов"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос или неизвестная категория"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера или транзакции"
+// @Security BearerAuth
+// @Router /products/category [put]
+func UpdateProductsCategory(c *gin.Context) {
+	var request models.UpdateProductsCategoryRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	var category models.Category
+	if err := requestDB(c).First(&category, request.CategoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCategoryNotFound, "Field 'category_id' refers to unknown category")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch category")
+		}
+		return
+	}
+
+	tx := requestDB(c).Begin()
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start transaction")
+		return
+	}
+
+	result := tx.Model(&models.Product{}).Where("id IN ?", request.ProductIDs).Updates(map[string]interface{}{
+		"category_id": category.ID,
+		"version":     gorm.Expr("version + 1"),
+	})
+	if err := result.Error; err != nil {
+		tx.Rollback()
+		log.Println("Error updating products category:", err)
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating products category")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Transaction commit failed")
+		return
+	}
+	recordAudit(c, models.AuditActionProductsCategorySet, fmt.Sprintf("category:%d", category.ID), models.StringMap{
+		"requested": strconv.Itoa(len(request.ProductIDs)),
+		"updated":   strconv.FormatInt(result.RowsAffected, 10),
+	})
+
+	c.JSON(http.StatusOK, models.UpdateProductsCategoryResponse{
+		Updated: result.RowsAffected,
+	})
+}
+
 // CountProductsByManufacturer godoc
 // @Summary Подсчет количества продуктов по производителям
 // @Description Выполняет агрегацию, подсчитывая количество продуктов, сгруппированных по производителям.
@@ -118,14 +225,10 @@ func UpdateProductsManufacturer(c *gin.Context) {
 // @Security BearerAuth
 // @Router /products/count-by-manufacturer [get]
 func CountProductsByManufacturer(c *gin.Context) {
-	var result []models.CountProdutsResponse
-
 	// Выполняем агрегацию по производителю и подсчитываем количество товаров
-	if err := services.DB.Model(&models.Product{}).
-		Select("manufacturer, COUNT(*) as count").
-		Group("manufacturer").
-		Scan(&result).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error counting products by manufacturer: "+err.Error())
+	result, err := countByManufacturer(requestDB(c).Model(&models.Product{}))
+	if err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error counting products by manufacturer: "+err.Error())
 		return
 	}
 
@@ -133,19 +236,89 @@ func CountProductsByManufacturer(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GetManufacturerStats godoc
+// @Summary Статистика цен по производителям
+// @Description Возвращает для каждого производителя количество продуктов, среднюю, минимальную и максимальную цену. Цены берутся в исходной валюте продуктов. Производители упорядочены по количеству продуктов по убыванию.
+// @Tags products
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Success 200 {array} models.ManufacturerStats "Статистика по производителям"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/stats-by-manufacturer [get]
+func GetManufacturerStats(c *gin.Context) {
+	stats := []models.ManufacturerStats{}
+	if err := requestDB(c).Model(&models.Product{}).
+		Select("manufacturer, COUNT(*) AS count, AVG(price) AS avg_price, MIN(price) AS min_price, MAX(price) AS max_price").
+		Group("manufacturer").
+		Order("count desc, manufacturer asc").
+		Scan(&stats).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching manufacturer stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetManufacturers godoc
+// @Summary Список производителей
+// @Description Возвращает отсортированный по имени список производителей с количеством продуктов у каждого. Можно ограничить выборку категорией.
+// @Tags products
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param category_id query int false "ID категории"
+// @Success 200 {array} models.CountProdutsResponse "Производители и количество их продуктов"
+// @Failure 400 {object} models.ErrorResponse "Некорректный ID категории"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/manufacturers [get]
+func GetManufacturers(c *gin.Context) {
+	query := requestDB(c).Model(&models.Product{}).Where("manufacturer <> ''")
+
+	if categoryIDParam := c.Query("category_id"); categoryIDParam != "" {
+		categoryID, err := strconv.Atoi(categoryIDParam)
+		if err != nil {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid category ID")
+			return
+		}
+		query = query.Where("category_id = ?", categoryID)
+	}
+
+	result, err := countByManufacturer(query.Order("manufacturer asc"))
+	if err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching manufacturers")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// countByManufacturer группирует продукты из запроса по производителю и считает их количество
+func countByManufacturer(query *gorm.DB) ([]models.CountProdutsResponse, error) {
+	result := []models.CountProdutsResponse{}
+	err := query.
+		Select("manufacturer, COUNT(*) as count").
+		Group("manufacturer").
+		Scan(&result).Error
+	return result, err
+}
+
 // GetProductsWithTimeout godoc
 // @Summary Получение списка продуктов с тайм-аутом
-// @Description Получает список продуктов с применением фильтров, сортировки и пагинации с тайм-аутом в 2 секунды
+// @Description Получает список продуктов с применением фильтров, сортировки и пагинации. Продукты возвращаются в кратком виде без описания; полные данные доступны по GET /products/{id}. Время выполнения запроса ограничено REQUEST_TIMEOUT.
+// @Description Размер страницы по умолчанию и его максимум задаются настройками DEFAULT_PAGE_LIMIT и MAX_PAGE_LIMIT (или PAGE_LIMIT_OVERRIDES для маршрута) и возвращаются в метаданных; при равных значениях поля сортировки продукты упорядочиваются по id.
 // @Tags products
 // @Accept  json
 // @Produce  json
 // @Param        Authorization header string false "токен"
 // @Param page query int false "Номер страницы" default(1)
 // @Param limit query int false "Количество элементов на странице" default(10)
-// @Param sort query string false "Поле для сортировки" default(id)
+// @Param sort query string false "Поле для сортировки: id, name, price, manufacturer, category_id, stock, rating, review_count" default(id)
 // @Param order query string false "Направление сортировки" default(asc)
-// @Param name query string false "Название продукта"
+// @Param name query string false "Строка поиска по названию и описанию продукта"
+// @Param normalize query bool false "Искать без учета диакритики (é = e)"
 // @Param category_id query string false "ID категории"
+// @Param currency query string false "Валюта, в которой вернуть цены (ISO 4217); сортировка по цене выполняется по исходным ценам"
 // @Success 200 {object} models.ProductResponse "Успешный запрос"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 404 {object} models.ErrorResponse "Продукты не найдены"
@@ -154,84 +327,427 @@ func CountProductsByManufacturer(c *gin.Context) {
 // @Security BearerAuth
 // @Router /products [get]
 func GetProductsWithTimeout(c *gin.Context) {
-	// Создаем контекст с тайм-аутом 2 секунды
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
-	defer cancel()
+	listProducts(c)
+}
 
-	var products []models.Product
+// listProducts отдает страницу продуктов с фильтрами, сортировкой и пагинацией из параметров запроса;
+// scopes дополнительно ограничивают выборку
+func listProducts(c *gin.Context, scopes ...func(*gorm.DB) *gorm.DB) {
+	var products []models.ProductSummary
 	var total int64
 
 	// Получаем параметры фильтров, сортировки и пагинации
-	page := c.DefaultQuery("page", "1")
-	limit := c.DefaultQuery("limit", "10")
 	sort := c.DefaultQuery("sort", "id")
 	order := c.DefaultQuery("order", "asc")
-	name := c.Query("name")
-	categoryID := c.Query("category_id")
 
-	// Преобразуем строковые параметры в int
-	pageInt, _ := strconv.Atoi(page)
-	limitInt, _ := strconv.Atoi(limit)
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
 	offset := (pageInt - 1) * limitInt
 
-	query := services.DB.Model(&models.Product{})
-
-	// Применяем фильтры
-	if name != "" {
-		query = query.Where("name ILIKE ?", "%"+name+"%")
-	}
-	if categoryID != "" {
-		query = query.Where("category_id = ?", categoryID)
+	currency := c.Query("currency")
+	if currency != "" && !services.IsKnownCurrency(currency) {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Unknown currency code")
+		return
 	}
 
-	query.Count(&total)
+	query := requestDB(c).Model(&models.Product{}).Scopes(productFilters(c)).Scopes(scopes...)
+
+	if err := query.Count(&total).Error; err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			utils.HandleError(c, http.StatusRequestTimeout, models.ErrCodeTimeout, "Request timed out")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+		}
+		return
+	}
 
 	// Применяем сортировку
+	sortColumn, ok := productSortColumns[sort]
+	if !ok {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid sort field")
+		return
+	}
 	if order != "asc" && order != "desc" {
 		order = "asc" // По умолчанию ascending
 	}
-	query = query.Order(sort + " " + order).Limit(limitInt).Offset(offset)
+	// Одинаковые значения упорядочиваем по id, чтобы страницы не пересекались
+	columns := productSummaryColumns
+	name := c.Query("name")
+	if name != "" {
+		// Описание нужно только для поиска совпадений, в ответ оно не попадает
+		columns += ", products.description"
+	}
+	query = query.Select(columns).Order(sortColumn + " " + order + ", products.id asc").Limit(limitInt).Offset(offset)
 
 	// Загружаем продукты с использованием контекста
-	if err := query.WithContext(ctx).Find(&products).Error; err != nil {
-		if err == context.DeadlineExceeded {
-			utils.HandleError(c, http.StatusRequestTimeout, "Request timed out")
+	if err := query.Find(&products).Error; err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			utils.HandleError(c, http.StatusRequestTimeout, models.ErrCodeTimeout, "Request timed out")
 		} else {
-			utils.HandleError(c, http.StatusInternalServerError, "Failed to fetch products")
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
 		}
 		return
 	}
 
+	// Позиции совпадений считаем после запроса, чтобы клиент мог подсветить найденное
+	if name != "" {
+		for i := range products {
+			for _, m := range utils.FindMatches(products[i].Name, name) {
+				products[i].Matches = append(products[i].Matches, models.SearchMatch{Field: "name", Start: m[0], End: m[1]})
+			}
+			for _, m := range utils.FindMatches(products[i].Description, name) {
+				products[i].Matches = append(products[i].Matches, models.SearchMatch{Field: "description", Start: m[0], End: m[1]})
+			}
+		}
+	}
+
+	if currency != "" {
+		currency = services.NormalizeCurrency(currency)
+		for i := range products {
+			price, err := services.ConvertPrice(products[i].Price, products[i].Currency, currency)
+			if err != nil {
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to convert prices")
+				return
+			}
+			products[i].Price = price
+			products[i].Currency = currency
+		}
+	}
+
 	// Возвращаем результат
 	c.JSON(http.StatusOK, models.ProductResponse{
-		Data:  products,
-		Total: total,
-		Page:  pageInt,
-		Limit: limitInt,
+		Data:       products,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
 	})
 }
 
+// productWithReviewCount выбирает продукт вместе с текущим количеством отзывов о нем
+const productWithReviewCount = "products.*, (SELECT COUNT(*) FROM reviews WHERE reviews.product_id = products.id) AS review_count"
+
+// productSummaryColumns выбирает только поля models.ProductSummary, не загружая описание продукта
+const productSummaryColumns = "products.id, products.name, products.category_id, products.price, products.currency, products.manufacturer, products.rating, " +
+	"(SELECT COUNT(*) FROM reviews WHERE reviews.product_id = products.id) AS review_count, " +
+	"GREATEST(products.stock - products.reserved, 0) AS available"
+
+// productSortColumns сопоставляет допустимые значения sort с выражениями для ORDER BY
+var productSortColumns = map[string]string{
+	"id":           "products.id",
+	"name":         "products.name",
+	"price":        "products.price",
+	"manufacturer": "products.manufacturer",
+	"category_id":  "products.category_id",
+	"stock":        "products.stock",
+	"rating":       "products.rating",
+	"review_count": "(SELECT COUNT(*) FROM reviews WHERE reviews.product_id = products.id)",
+}
+
+// productFilters применяет фильтры списка продуктов из параметров запроса
+func productFilters(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if name := c.Query("name"); name != "" {
+			// Ищем и по названию, и по описанию. Без расширения unaccent normalize игнорируется
+			// и поиск остается только регистронезависимым
+			pattern := "%" + name + "%"
+			if c.Query("normalize") == "true" && services.UnaccentAvailable {
+				db = db.Where("(unaccent(products.name) ILIKE unaccent(?) OR unaccent(products.description) ILIKE unaccent(?))", pattern, pattern)
+			} else {
+				db = db.Where("(products.name ILIKE ? OR products.description ILIKE ?)", pattern, pattern)
+			}
+		}
+		if categoryID := c.Query("category_id"); categoryID != "" {
+			db = db.Where("category_id = ?", categoryID)
+		}
+		return db
+	}
+}
+
+// ExportProductsCSV godoc
+// @Summary Экспорт продуктов в CSV
+// @Description Выгружает каталог продуктов в формате CSV с учетом тех же фильтров, что и список продуктов. Строки передаются потоком; время выгрузки ограничено EXPORT_TIMEOUT.
+// @Tags products
+// @Produce text/csv
+// @Param Authorization header string false "токен"
+// @Param name query string false "Строка поиска по названию и описанию продукта"
+// @Param normalize query bool false "Искать без учета диакритики (é = e)"
+// @Param category_id query string false "ID категории"
+// @Success 200 {file} file "CSV-файл с продуктами"
+// @Header 200 {string} X-Export-Status "Трейлер: complete, если выгрузка завершена, incomplete — если прервана"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/export [get]
+func ExportProductsCSV(c *gin.Context) {
+	rows, err := requestDB(c).
+		Model(&models.Product{}).
+		Scopes(productFilters(c)).
+		Order("id asc").
+		Rows()
+	if err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="products.csv"`)
+	// Ошибка после начала выгрузки уже не может изменить статус, поэтому о ней сообщает трейлер
+	c.Header("Trailer", exportStatusTrailer)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"id", "name", "description", "category_id", "price", "manufacturer", "rating"})
+
+	var exportErr error
+	for rows.Next() {
+		var product models.Product
+		if exportErr = requestDB(c).ScanRows(rows, &product); exportErr != nil {
+			break
+		}
+
+		writer.Write([]string{
+			strconv.Itoa(product.ID),
+			product.Name,
+			product.Description,
+			strconv.Itoa(product.CategoryID),
+			strconv.FormatFloat(product.Price, 'f', 2, 64),
+			product.Manufacturer,
+			strconv.FormatFloat(product.Rating, 'f', 2, 64),
+		})
+	}
+
+	if exportErr == nil {
+		// Next возвращает false и при ошибке: отмена по сроку или обрыв соединения с базой
+		exportErr = rows.Err()
+	}
+
+	writer.Flush()
+	if exportErr == nil {
+		exportErr = writer.Error()
+	}
+
+	if exportErr != nil {
+		log.Println("Error writing CSV export:", exportErr)
+		c.Writer.Header().Set(exportStatusTrailer, "incomplete")
+		c.Error(exportErr)
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Set(exportStatusTrailer, "complete")
+}
+
+// exportStatusTrailer — трейлер ответа с итогом потоковой выгрузки: complete или incomplete
+const exportStatusTrailer = "X-Export-Status"
+
 // GetProductByID godoc
 // @Summary Получение продукта по ID
-// @Description Получает информацию о продукте по уникальному идентификатору
+// @Description Получает информацию о продукте по уникальному идентификатору. Поддерживает условный запрос через If-None-Match.
 // @Tags products
 // @Produce  json
 // @Param        Authorization header string false "токен"
 // @Param        id path string true "ID продукта"
+// @Param        include_deleted query bool false "Включить удаленные продукты (только для администраторов)"
+// @Param        If-None-Match header string false "ETag ранее полученного ответа"
 // @Success 200 {object} models.Product "Успешный запрос"
+// @Header 200 {string} ETag "Хеш представления продукта"
+// @Success 304 "Продукт не изменился"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
 // @Failure 404 {object} models.ErrorResponse "Продукт не найден"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /products/{id} [get]
 func GetProductByID(c *gin.Context) {
 	id := c.Param("id")
+
+	query := requestDB(c)
+	if c.Query("include_deleted") == "true" {
+		if role, _ := c.Get("role"); role != "admin" {
+			utils.HandleError(c, http.StatusForbidden, models.ErrCodeForbidden, "forbidden")
+			return
+		}
+		query = query.Unscoped()
+	}
+
 	var product models.Product
-	if err := services.DB.First(&product, id).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Product not found")
+	if err := query.Select(productWithReviewCount).First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeProductNotFound, "Product not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch product")
 		return
 	}
-	c.JSON(http.StatusOK, product)
+	utils.JSONWithETag(c, product)
+}
 
+// GetRelatedProducts godoc
+// @Summary Похожие продукты
+// @Description Возвращает продукты из той же категории, исключая сам продукт, по убыванию рейтинга. Если похожих продуктов нет, возвращается пустой список.
+// @Tags products
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        id path int true "ID продукта"
+// @Param        limit query int false "Максимальное количество продуктов" default(5)
+// @Success 200 {array} models.ProductSummary "Похожие продукты"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Продукт не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/{id}/related [get]
+func GetRelatedProducts(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid product ID")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Incorrect limit")
+		return
+	}
+	if limit > utils.PageLimitsFor(c).Max {
+		limit = utils.PageLimitsFor(c).Max
+	}
+
+	var product models.Product
+	if err := requestDB(c).First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeProductNotFound, "Product not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch product")
+		}
+		return
+	}
+
+	related := []models.ProductSummary{}
+	if err := requestDB(c).Model(&models.Product{}).
+		Select(productSummaryColumns).
+		Where("category_id = ? AND id <> ?", product.CategoryID, product.ID).
+		Order("rating desc, id asc").
+		Limit(limit).
+		Find(&related).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+		return
+	}
+
+	c.JSON(http.StatusOK, related)
+}
+
+// GetLowStockProducts godoc
+// @Summary Заканчивающиеся продукты
+// @Description Возвращает продукты с остатком на складе не больше порога, по возрастанию остатка. Порог по умолчанию задается LOW_STOCK_THRESHOLD.
+// @Tags products
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        threshold query int false "Порог остатка"
+// @Success 200 {array} models.Product "Продукты с низким остатком"
+// @Failure 400 {object} models.ErrorResponse "Некорректный порог"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/products/low-stock [get]
+func GetLowStockProducts(c *gin.Context) {
+	threshold := services.AppConfig.LowStockThreshold
+	if raw := c.Query("threshold"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Threshold must be a non-negative integer")
+			return
+		}
+		threshold = value
+	}
+
+	products := []models.Product{}
+	if err := requestDB(c).Where("stock <= ?", threshold).Order("stock asc, id asc").Find(&products).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// orphanedProducts выбирает продукты, чья категория не существует
+func orphanedProducts(db *gorm.DB) *gorm.DB {
+	return db.Where("NOT EXISTS (SELECT 1 FROM categories WHERE categories.id = products.category_id)")
+}
+
+// GetOrphanedProducts godoc
+// @Summary Продукты без категории
+// @Description Возвращает продукты, которые ссылаются на несуществующую категорию, например после удаления категории или импорта данных.
+// @Tags products
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Success 200 {array} models.Product "Продукты без категории"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/products/orphaned [get]
+func GetOrphanedProducts(c *gin.Context) {
+	products := []models.Product{}
+	if err := requestDB(c).Scopes(orphanedProducts).Order("id asc").Find(&products).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// RepairOrphanedProducts godoc
+// @Summary Перенос продуктов без категории
+// @Description Переносит продукты, которые ссылаются на несуществующую категорию, в категорию UNCATEGORIZED_CATEGORY_NAME.
+// @Tags products
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Success 200 {object} models.RepairOrphanedProductsResponse "Категория и число перенесенных продуктов"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/products/orphaned/repair [post]
+func RepairOrphanedProducts(c *gin.Context) {
+	tx := requestDB(c).Begin()
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start transaction")
+		return
+	}
+
+	// Блокируем найденные продукты, чтобы в журнал попали ровно те, что были перенесены
+	var ids []int
+	if err := tx.Model(&models.Product{}).Scopes(orphanedProducts).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Order("id asc").Pluck("id", &ids).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+		return
+	}
+
+	var updated int64
+	if len(ids) > 0 {
+		result := tx.Model(&models.Product{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"category_id": services.UncategorizedCategoryID,
+			"version":     gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to repair products")
+			return
+		}
+		updated = result.RowsAffected
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Transaction commit failed")
+		return
+	}
+	if updated > 0 {
+		recordAudit(c, models.AuditActionProductsOrphanRepair, fmt.Sprintf("category:%d", services.UncategorizedCategoryID), models.StringMap{
+			"updated": strconv.FormatInt(updated, 10),
+			"ids":     joinIDs(ids),
+		})
+	}
+
+	c.JSON(http.StatusOK, models.RepairOrphanedProductsResponse{
+		CategoryID: services.UncategorizedCategoryID,
+		Updated:    updated,
+	})
 }
 
 // CreateProduct godoc
@@ -241,76 +757,494 @@ func GetProductByID(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param        Authorization header string false "токен"
-// @Param        product body models.Product true "Данные продукта"
+// @Param        product body models.ProductRequest true "Данные продукта"
 // @Success 201 {object} models.Product "Успешное создание"
+// @Header 201 {string} Location "Адрес созданного продукта"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 422 {object} models.ErrorResponse "Ошибки в полях продукта, перечисленные в fields"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /products [post]
 func CreateProduct(c *gin.Context) {
-	var newProduct models.Product
+	var request models.ProductRequest
 
-	if err := c.BindJSON(&newProduct); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request")
+	if err := utils.BindJSONStrict(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Invalid request: "+err.Error())
 		return
 	}
+	newProduct := request.Product()
 
-	var category models.Category
-	if err := services.DB.First(&category, newProduct.CategoryID).Error; err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid category ID")
+	if err := validateNewProduct(requestDB(c), &newProduct); err != nil {
+		utils.HandleValidationError(c, err)
 		return
 	}
+	newProduct.Currency = services.NormalizeCurrency(newProduct.Currency)
+	newProduct.Version = 1
 
-	if newProduct.Price <= 0 {
-		utils.HandleError(c, http.StatusBadRequest, "Price must be greater than 0")
+	if err := requestDB(c).Create(&newProduct).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create product")
 		return
 	}
-
-	services.DB.Create(&newProduct)
+	recordAudit(c, models.AuditActionProductCreate, fmt.Sprintf("product:%d", newProduct.ID), models.StringMap{
+		"name": newProduct.Name,
+	})
+	c.Header("Location", fmt.Sprintf("/products/%d", newProduct.ID))
 	c.JSON(http.StatusCreated, newProduct)
+}
+
+// CreateProductsBulk godoc
+// @Summary Массовое создание продуктов
+// @Description Создает несколько продуктов в одной транзакции. Если хотя бы один продукт некорректен, не создается ни один.
+// @Tags products
+// @Accept  json
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        products body []models.ProductRequest true "Список продуктов"
+// @Success 201 {array} models.Product "Созданные продукты"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос или продукт"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/bulk [post]
+func CreateProductsBulk(c *gin.Context) {
+	var requests []models.ProductRequest
+
+	if err := utils.BindJSONStrict(c, &requests); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Invalid request: "+err.Error())
+		return
+	}
+
+	if len(requests) == 0 {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Products list must not be empty")
+		return
+	}
 
+	newProducts := make([]models.Product, len(requests))
+	for i, request := range requests {
+		newProducts[i] = request.Product()
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	for i := range newProducts {
+		if err := validateNewProduct(tx, &newProducts[i]); err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, fmt.Sprintf("Product at index %d: %s", i, err.Error()))
+			return
+		}
+		newProducts[i].Currency = services.NormalizeCurrency(newProducts[i].Currency)
+		newProducts[i].Version = 1
+	}
+
+	if err := tx.Create(&newProducts).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create products")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+	createdIDs := make([]int, len(newProducts))
+	for i, product := range newProducts {
+		createdIDs[i] = product.ID
+	}
+	recordAudit(c, models.AuditActionProductsCreate, "products:bulk", models.StringMap{
+		"created": strconv.Itoa(len(newProducts)),
+		"ids":     joinIDs(createdIDs),
+	})
+
+	c.JSON(http.StatusCreated, newProducts)
+}
+
+// sanitizeProductText очищает текстовые поля продукта от управляющих символов
+func sanitizeProductText(product *models.Product) utils.FieldErrors {
+	var errs utils.FieldErrors
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"name", &product.Name},
+		{"description", &product.Description},
+		{"manufacturer", &product.Manufacturer},
+	}
+	for _, field := range fields {
+		sanitized, err := utils.SanitizeText(field.name, *field.value)
+		if err != nil {
+			errs.Add(field.name, err.Error())
+			continue
+		}
+		*field.value = sanitized
+	}
+	return errs
+}
+
+// validateNewProduct очищает текстовые поля создаваемого продукта и проверяет все его поля и существование категории.
+// Ошибки возвращаются вместе как utils.FieldErrors
+func validateNewProduct(db *gorm.DB, product *models.Product) error {
+	errs := sanitizeProductText(product)
+
+	if strings.TrimSpace(product.Name) == "" {
+		errs.Add("name", "Field 'name' must not be empty")
+	}
+
+	if product.Price <= 0 {
+		errs.Add("price", "Field 'price' must be greater than 0")
+	}
+
+	if product.Stock < 0 {
+		errs.Add("stock", "Field 'stock' must not be negative")
+	}
+
+	if !services.IsKnownCurrency(product.Currency) {
+		errs.Add("currency", "Field 'currency' must be a supported ISO 4217 code")
+	}
+
+	if err := utils.ValidateImageURLs(product.ImageURLs, services.AppConfig.MaxProductImages); err != nil {
+		errs.Add("image_urls", err.Error())
+	}
+
+	var category models.Category
+	if err := db.First(&category, product.CategoryID).Error; err != nil {
+		errs.Add("category_id", "Field 'category_id' refers to unknown category")
+	}
+
+	return errs.Err()
 }
 
 // UpdateProduct godoc
 // @Summary Обновление продукта
-// @Description Обновляет данные продукта по указанному ID
+// @Description Обновляет данные продукта по указанному ID. При смене category_id новая категория должна существовать.
+// @Description Поле version должно совпадать с текущей версией продукта, иначе изменения другого администратора не перезаписываются и возвращается 409.
 // @Tags products
 // @Accept  json
 // @Produce  json
 // @Param        Authorization header string false "токен"
 // @Param        id path int true "ID продукта"
-// @Param        product body models.Product true "Обновленные данные продукта"
+// @Param        product body models.ProductRequest true "Обновленные данные продукта"
 // @Success 200 {object} models.Product "Успешное обновление"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 404 {object} models.ErrorResponse "Продукт не найден"
+// @Failure 409 {object} models.ErrorResponse "Продукт изменен другим запросом"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /products/{id} [put]
 func UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
-	var updatedProduct models.Product
+	var request models.ProductRequest
+
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+		return
+	}
+	// В модель попадают только редактируемые поля, поэтому Updates не тронет рейтинг, резерв и прочие служебные поля
+	updatedProduct := request.Product()
+
+	if err := sanitizeProductText(&updatedProduct).Err(); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
 
-	if err := c.BindJSON(&updatedProduct); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request")
+	if updatedProduct.Version < 1 {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'version' is required")
 		return
 	}
+	expectedVersion := updatedProduct.Version
 
 	if updatedProduct.Price <= 0 {
-		utils.HandleError(c, http.StatusBadRequest, "Price must be greater than 0")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Price must be greater than 0")
+		return
+	}
+
+	if updatedProduct.Stock < 0 {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Stock must not be negative")
+		return
+	}
+
+	if err := utils.ValidateImageURLs(updatedProduct.ImageURLs, services.AppConfig.MaxProductImages); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	if updatedProduct.Currency != "" {
+		if !services.IsKnownCurrency(updatedProduct.Currency) {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'currency' must be a supported ISO 4217 code")
+			return
+		}
+		updatedProduct.Currency = services.NormalizeCurrency(updatedProduct.Currency)
+	}
+
+	var product models.Product
+	if err := requestDB(c).First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeProductNotFound, "Product not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch product")
+		}
+		return
+	}
+
+	// Нулевой category_id Updates пропускает, поэтому проверяем только реальную смену категории
+	if updatedProduct.CategoryID != 0 && updatedProduct.CategoryID != product.CategoryID {
+		var category models.Category
+		if err := requestDB(c).First(&category, updatedProduct.CategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCategoryNotFound, "Field 'category_id' refers to unknown category")
+			} else {
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch category")
+			}
+			return
+		}
+	}
+
+	if product.Version != expectedVersion {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeConflict, "Product was modified by another request, reload it and retry")
+		return
+	}
+
+	// Условие на версию защищает от параллельного изменения между чтением и записью
+	updatedProduct.Version = expectedVersion + 1
+	result := requestDB(c).Model(&product).Where("version = ?", expectedVersion).Updates(updatedProduct)
+	if result.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update product")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeConflict, "Product was modified by another request, reload it and retry")
+		return
+	}
+
+	if err := requestDB(c).First(&product, product.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch updated product")
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// PatchProduct godoc
+// @Summary Частичное обновление продукта
+// @Description Обновляет только переданные поля продукта. Непереданные поля остаются без изменений, а переданные нулевые значения записываются явно.
+// @Description Поле version обязательно и должно совпадать с текущей версией продукта, иначе возвращается 409.
+// @Tags products
+// @Accept  json
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        id path int true "ID продукта"
+// @Param        product body models.PatchProductRequest true "Изменяемые поля продукта"
+// @Success 200 {object} models.Product "Обновленный продукт"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Продукт не найден"
+// @Failure 409 {object} models.ErrorResponse "Продукт изменен другим запросом"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/{id} [patch]
+func PatchProduct(c *gin.Context) {
+	id := c.Param("id")
+	var request models.PatchProductRequest
+
+	if err := utils.BindJSONStrict(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Invalid request: "+err.Error())
+		return
+	}
+
+	if request.Version == nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'version' is required")
+		return
+	}
+
+	var product models.Product
+	if err := requestDB(c).First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeProductNotFound, "Product not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch product")
+		}
+		return
+	}
+
+	if product.Version != *request.Version {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeConflict, "Product was modified by another request, reload it and retry")
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	textFields := []struct {
+		column string
+		value  *string
+	}{
+		{"name", request.Name},
+		{"description", request.Description},
+		{"manufacturer", request.Manufacturer},
+	}
+	for _, field := range textFields {
+		if field.value == nil {
+			continue
+		}
+		sanitized, err := utils.SanitizeText(field.column, *field.value)
+		if err != nil {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+			return
+		}
+		*field.value = sanitized
+	}
+
+	if request.Name != nil {
+		if strings.TrimSpace(*request.Name) == "" {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'name' must not be empty")
+			return
+		}
+		updates["name"] = *request.Name
+	}
+	if request.Description != nil {
+		updates["description"] = *request.Description
+	}
+	if request.CategoryID != nil {
+		var category models.Category
+		if err := requestDB(c).First(&category, *request.CategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.HandleError(c, http.StatusBadRequest, models.ErrCodeCategoryNotFound, "Field 'category_id' refers to unknown category")
+			} else {
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch category")
+			}
+			return
+		}
+		updates["category_id"] = *request.CategoryID
+	}
+	if request.Price != nil {
+		if *request.Price <= 0 {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'price' must be greater than 0")
+			return
+		}
+		updates["price"] = *request.Price
+	}
+	if request.Currency != nil {
+		if *request.Currency == "" || !services.IsKnownCurrency(*request.Currency) {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'currency' must be a supported ISO 4217 code")
+			return
+		}
+		updates["currency"] = services.NormalizeCurrency(*request.Currency)
+	}
+	if request.Manufacturer != nil {
+		updates["manufacturer"] = *request.Manufacturer
+	}
+	if request.Stock != nil {
+		if *request.Stock < 0 {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'stock' must not be negative")
+			return
+		}
+		updates["stock"] = *request.Stock
+	}
+	if request.ImageURLs != nil {
+		if err := utils.ValidateImageURLs(*request.ImageURLs, services.AppConfig.MaxProductImages); err != nil {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+			return
+		}
+		updates["image_urls"] = models.StringList(*request.ImageURLs)
+	}
+
+	if len(updates) > 0 {
+		updates["version"] = gorm.Expr("version + 1")
+		result := requestDB(c).Model(&product).Where("version = ?", *request.Version).Updates(updates)
+		if result.Error != nil {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update product")
+			return
+		}
+		if result.RowsAffected == 0 {
+			utils.HandleError(c, http.StatusConflict, models.ErrCodeConflict, "Product was modified by another request, reload it and retry")
+			return
+		}
+	}
+
+	if err := requestDB(c).First(&product, product.ID).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch updated product")
 		return
 	}
 
-	if err := services.DB.Model(&models.Product{}).Where("id = ?", id).Updates(updatedProduct).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Product not found")
+	c.JSON(http.StatusOK, product)
+}
+
+// DeleteProducts godoc
+// @Summary Массовое удаление продуктов
+// @Description Мягко удаляет продукты с переданными ID в одной транзакции. ID, которым не соответствует продукт, возвращаются в not_found.
+// @Tags products
+// @Accept  json
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        request body models.DeleteProductsRequest true "ID удаляемых продуктов"
+// @Success 200 {object} models.DeleteProductsResponse "Количество удаленных продуктов и ненайденные ID"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products [delete]
+func DeleteProducts(c *gin.Context) {
+	var request models.DeleteProductsRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedProduct)
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	var found []int
+	if err := tx.Model(&models.Product{}).Where("id IN ?", request.IDs).Pluck("id", &found).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+		return
+	}
+
+	response := models.DeleteProductsResponse{NotFound: []int{}}
+
+	if len(found) > 0 {
+		result := tx.Where("id IN ?", found).Delete(&models.Product{})
+		if result.Error != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete products")
+			return
+		}
+		response.Deleted = result.RowsAffected
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+	if response.Deleted > 0 {
+		recordAudit(c, models.AuditActionProductsDelete, "products:bulk", models.StringMap{
+			"deleted": strconv.FormatInt(response.Deleted, 10),
+			"ids":     joinIDs(found),
+		})
+	}
+
+	existing := make(map[int]bool, len(found))
+	for _, id := range found {
+		existing[id] = true
+	}
+	for _, id := range request.IDs {
+		if !existing[id] {
+			// Повторы в запросе попадают в ответ один раз
+			existing[id] = true
+			response.NotFound = append(response.NotFound, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteProduct godoc
 // @Summary Удаление продукта
-// @Description Удаляет продукт по указанному ID
+// @Description Мягко удаляет продукт по указанному ID. Продукт остается доступен в истории заказов.
 // @Tags products
 // @Produce  json
 // @Param        Authorization header string false "токен"
@@ -323,11 +1257,205 @@ func UpdateProduct(c *gin.Context) {
 func DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := services.DB.Delete(&models.Product{}, id).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "Product not found")
+	result := requestDB(c).Delete(&models.Product{}, id)
+	if result.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete product")
 		return
 	}
+	if result.RowsAffected == 0 {
+		utils.HandleError(c, http.StatusNotFound, models.ErrCodeProductNotFound, "Product not found")
+		return
+	}
+	recordAudit(c, models.AuditActionProductDelete, "product:"+id, nil)
 	c.JSON(http.StatusOK, models.MessageResponse{
 		Message: "product deleted",
 	})
 }
+
+// MergeProduct godoc
+// @Summary Слияние продукта-дубликата
+// @Description Переносит отзывы, позиции заказов, резервы, варианты и остаток продукта id на продукт target_id, после чего удаляет продукт id (мягкое удаление). Рейтинг target_id пересчитывается.
+// @Description Если в заказе уже есть позиция target_id с тем же вариантом и той же ценой покупки, количество позиции дубликата добавляется к ней. Если вариант или цена различаются, слияние отклоняется с кодом 409 и списком таких заказов. Отзыв автора, уже оценившего target_id, удаляется, чтобы сохранить правило одного отзыва на продукт.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "токен"
+// @Param id path int true "ID продукта-дубликата"
+// @Param request body models.MergeProductRequest true "ID продукта, который остается"
+// @Success 200 {object} models.MergeProductResponse "Итог слияния"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос или неизвестный target_id"
+// @Failure 404 {object} models.ErrorResponse "Продукт не найден"
+// @Failure 409 {object} models.ErrorResponse "В заказах есть позиции обоих продуктов с разными вариантами или ценами"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/products/{id}/merge [post]
+func MergeProduct(c *gin.Context) {
+	sourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid product ID")
+		return
+	}
+
+	var request models.MergeProductRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+	if request.TargetID == sourceID {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'target_id' must differ from the merged product")
+		return
+	}
+
+	tx := requestDB(c).Begin()
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start transaction")
+		return
+	}
+
+	// Блокируем оба продукта, чтобы параллельные заказы не меняли их остатки во время слияния
+	var products []models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", []int{sourceID, request.TargetID}).
+		Find(&products).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+		return
+	}
+	var source, target *models.Product
+	for i := range products {
+		if products[i].ID == sourceID {
+			source = &products[i]
+		} else {
+			target = &products[i]
+		}
+	}
+	if source == nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusNotFound, models.ErrCodeProductNotFound, "Product not found")
+		return
+	}
+	if target == nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeProductNotFound, "Field 'target_id' refers to unknown product")
+		return
+	}
+
+	response := models.MergeProductResponse{TargetID: target.ID}
+
+	fail := func(message string, err error) {
+		tx.Rollback()
+		log.Printf("Error merging product %d into %d: %v", source.ID, target.ID, err)
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, message)
+	}
+
+	// В заказе может быть только одна позиция продукта. Позицию дубликата можно сложить с позицией target
+	// только при том же варианте и той же цене покупки, иначе заказ изменился бы, поэтому при таких заказах слияние отклоняется
+	var conflicting []int
+	if err := tx.Raw(`SELECT s.order_id FROM order_products AS s
+		JOIN order_products AS t ON t.order_id = s.order_id AND t.product_id = ?
+		WHERE s.product_id = ?
+		AND (s.variant_id IS DISTINCT FROM t.variant_id OR s.price_at_purchase <> t.price_at_purchase)
+		ORDER BY s.order_id`, target.ID, source.ID).Scan(&conflicting).Error; err != nil {
+		fail("Failed to check order lines", err)
+		return
+	}
+	if len(conflicting) > 0 {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeConflict,
+			fmt.Sprintf("Orders %v contain both products with different variants or prices", conflicting))
+		return
+	}
+
+	// Позиции заказов: в заказах, где уже есть такая же позиция target, количество складывается, в остальных позиция переносится
+	merged := tx.Exec(`UPDATE order_products AS t SET quantity = t.quantity + s.quantity
+		FROM order_products AS s
+		WHERE s.order_id = t.order_id AND s.product_id = ? AND t.product_id = ?
+		AND s.variant_id IS NOT DISTINCT FROM t.variant_id AND s.price_at_purchase = t.price_at_purchase`, source.ID, target.ID)
+	if merged.Error != nil {
+		fail("Failed to merge order lines", merged.Error)
+		return
+	}
+	response.MergedOrderLines = merged.RowsAffected
+	if err := tx.Exec(`DELETE FROM order_products AS s WHERE s.product_id = ?
+		AND EXISTS (SELECT 1 FROM order_products AS t WHERE t.order_id = s.order_id AND t.product_id = ?
+			AND t.variant_id IS NOT DISTINCT FROM s.variant_id AND t.price_at_purchase = s.price_at_purchase)`, source.ID, target.ID).Error; err != nil {
+		fail("Failed to merge order lines", err)
+		return
+	}
+	moved := tx.Model(&models.OrderProduct{}).Where("product_id = ?", source.ID).Update("product_id", target.ID)
+	if moved.Error != nil {
+		fail("Failed to move order lines", moved.Error)
+		return
+	}
+	response.MovedOrderLines = moved.RowsAffected
+
+	// Резервы переносятся так же, как позиции заказов: после проверки выше резерв target в том же заказе
+	// относится к позиции, с которой сложилась позиция дубликата
+	if err := tx.Exec(`UPDATE stock_reservations AS t SET quantity = t.quantity + s.quantity
+		FROM stock_reservations AS s
+		WHERE s.order_id = t.order_id AND s.product_id = ? AND t.product_id = ?`, source.ID, target.ID).Error; err != nil {
+		fail("Failed to merge reservations", err)
+		return
+	}
+	if err := tx.Exec(`DELETE FROM stock_reservations WHERE product_id = ?
+		AND order_id IN (SELECT order_id FROM stock_reservations WHERE product_id = ?)`, source.ID, target.ID).Error; err != nil {
+		fail("Failed to merge reservations", err)
+		return
+	}
+	if err := tx.Model(&models.StockReservation{}).Where("product_id = ?", source.ID).Update("product_id", target.ID).Error; err != nil {
+		fail("Failed to move reservations", err)
+		return
+	}
+
+	if err := tx.Model(&models.ProductVariant{}).Unscoped().Where("product_id = ?", source.ID).Update("product_id", target.ID).Error; err != nil {
+		fail("Failed to move product variants", err)
+		return
+	}
+
+	// Отзывы: у одного автора остается один отзыв на продукт
+	dropped := tx.Where("product_id = ? AND user_id IN (SELECT user_id FROM reviews WHERE product_id = ?)", source.ID, target.ID).
+		Delete(&models.Review{})
+	if dropped.Error != nil {
+		fail("Failed to merge reviews", dropped.Error)
+		return
+	}
+	response.DroppedReviews = dropped.RowsAffected
+	movedReviews := tx.Model(&models.Review{}).Where("product_id = ?", source.ID).Update("product_id", target.ID)
+	if movedReviews.Error != nil {
+		fail("Failed to move reviews", movedReviews.Error)
+		return
+	}
+	response.MovedReviews = movedReviews.RowsAffected
+
+	if err := tx.Model(target).Updates(map[string]interface{}{
+		"stock":    gorm.Expr("stock + ?", source.Stock),
+		"reserved": gorm.Expr("reserved + ?", source.Reserved),
+		"version":  gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		fail("Failed to update target product", err)
+		return
+	}
+	if err := updateProductRating(tx, target.ID); err != nil {
+		fail("Failed to update product rating", err)
+		return
+	}
+
+	if err := tx.Model(source).Updates(map[string]interface{}{"stock": 0, "reserved": 0}).Error; err != nil {
+		fail("Failed to update merged product", err)
+		return
+	}
+	if err := tx.Delete(source).Error; err != nil {
+		fail("Failed to delete merged product", err)
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to merge products")
+		return
+	}
+	recordAudit(c, models.AuditActionProductMerge, fmt.Sprintf("product:%d", source.ID), models.StringMap{
+		"target_id": strconv.Itoa(target.ID),
+	})
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"project/services"
+	"testing"
+)
+
+func createReview(t *testing.T, user models.User, product models.Product, rating int) {
+	t.Helper()
+	review := models.Review{UserID: user.ID, ProductID: product.ID, Rating: rating, ReviewText: "review"}
+	if err := services.DB.Omit("User", "Product").Create(&review).Error; err != nil {
+		t.Fatalf("create review: %v", err)
+	}
+}
+
+func mergeProducts(t *testing.T, admin models.User, source, target models.Product) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, MergeProduct, testRequest{
+		method: http.MethodPost,
+		route:  "/admin/products/:id/merge",
+		target: fmt.Sprintf("/admin/products/%d/merge", source.ID),
+		user:   &admin,
+		body:   models.MergeProductRequest{TargetID: target.ID},
+	})
+}
+
+func TestMergeProductMovesReviewsAndOrderLines(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	alice := createUser(t, models.RoleUser)
+	bob := createUser(t, models.RoleUser)
+	carol := createUser(t, models.RoleUser)
+	source := createProduct(t, 10, 5)
+	target := createProduct(t, 10, 3)
+
+	createReview(t, alice, source, 5)
+	createReview(t, bob, source, 1)
+	createReview(t, bob, target, 3)
+	createReview(t, carol, target, 4)
+
+	both := createOrder(t, alice, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: source.ID, Quantity: 2, PriceAtPurchase: 10},
+		models.OrderProduct{ProductID: target.ID, Quantity: 1, PriceAtPurchase: 10})
+	sourceOnly := createOrder(t, bob, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: source.ID, Quantity: 4, PriceAtPurchase: 10})
+
+	recorder := mergeProducts(t, admin, source, target)
+	assertStatus(t, recorder, http.StatusOK)
+	var response models.MergeProductResponse
+	decodeBody(t, recorder, &response)
+	want := models.MergeProductResponse{TargetID: target.ID, MovedReviews: 1, DroppedReviews: 1, MovedOrderLines: 1, MergedOrderLines: 1}
+	if response != want {
+		t.Fatalf("response = %+v, want %+v", response, want)
+	}
+
+	var reviews []models.Review
+	services.DB.Where("product_id = ?", target.ID).Order("user_id").Find(&reviews)
+	if len(reviews) != 3 || reviews[0].UserID != alice.ID || reviews[1].Rating != 3 || reviews[2].UserID != carol.ID {
+		t.Fatalf("target reviews = %+v, want alice's moved review and one review per author", reviews)
+	}
+
+	merged := reloadProduct(t, target.ID)
+	if merged.Rating != 4 {
+		t.Fatalf("target rating = %v, want 4", merged.Rating)
+	}
+	if merged.Stock != 8 {
+		t.Fatalf("target stock = %d, want 8", merged.Stock)
+	}
+	if removed := reloadProduct(t, source.ID); !removed.DeletedAt.Valid {
+		t.Fatal("merged product is not deleted")
+	}
+
+	var left int64
+	services.DB.Model(&models.OrderProduct{}).Where("product_id = ?", source.ID).Count(&left)
+	if left != 0 {
+		t.Fatalf("%d order lines still refer to the merged product", left)
+	}
+	for orderID, quantity := range map[int]int{both.ID: 3, sourceOnly.ID: 4} {
+		var line models.OrderProduct
+		if err := services.DB.Where("order_id = ? AND product_id = ?", orderID, target.ID).First(&line).Error; err != nil {
+			t.Fatalf("order %d has no target line: %v", orderID, err)
+		}
+		if line.Quantity != quantity {
+			t.Fatalf("order %d target quantity = %d, want %d", orderID, line.Quantity, quantity)
+		}
+	}
+}
+
+func TestMergeProductRejectsLinesWithDifferentPrices(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	user := createUser(t, models.RoleUser)
+	source := createProduct(t, 10, 5)
+	target := createProduct(t, 12, 3)
+	order := createOrder(t, user, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: source.ID, Quantity: 2, PriceAtPurchase: 10},
+		models.OrderProduct{ProductID: target.ID, Quantity: 1, PriceAtPurchase: 12})
+
+	recorder := mergeProducts(t, admin, source, target)
+	assertErrorCode(t, recorder, http.StatusConflict, models.ErrCodeConflict)
+
+	var lines []models.OrderProduct
+	services.DB.Where("order_id = ?", order.ID).Order("product_id").Find(&lines)
+	if len(lines) != 2 || lines[0].Quantity != 2 || lines[1].Quantity != 1 {
+		t.Fatalf("order lines = %+v, want both lines unchanged", lines)
+	}
+	if kept := reloadProduct(t, source.ID); kept.DeletedAt.Valid {
+		t.Fatal("source product deleted despite the conflict")
+	}
+}
+
+func TestGetProductByIDConditionalRequest(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	getProduct := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := testRequest{method: http.MethodGet, route: "/products/:id", target: fmt.Sprintf("/products/%d", product.ID), user: &user}
+		if ifNoneMatch != "" {
+			req.header = map[string]string{"If-None-Match": ifNoneMatch}
+		}
+		return perform(t, GetProductByID, req)
+	}
+
+	first := getProduct("")
+	assertStatus(t, first, http.StatusOK)
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag header is missing")
+	}
+
+	cached := getProduct(etag)
+	assertStatus(t, cached, http.StatusNotModified)
+	if cached.Body.Len() != 0 {
+		t.Fatalf("304 response has a body: %q", cached.Body.String())
+	}
+
+	services.DB.Model(&product).Update("price", 12)
+	changed := getProduct(etag)
+	assertStatus(t, changed, http.StatusOK)
+	if changed.Header().Get("ETag") == etag {
+		t.Fatal("ETag did not change after the product changed")
+	}
+}
+
+func updateProduct(t *testing.T, admin models.User, product models.Product, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, UpdateProduct, testRequest{
+		method: http.MethodPut, route: "/products/:id", target: fmt.Sprintf("/products/%d", product.ID), user: &admin, body: body,
+	})
+}
+
+func TestUpdateProductRejectsStaleVersion(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	product := createProduct(t, 10, 5)
+	request := models.ProductRequest{Name: "Renamed", CategoryID: product.CategoryID, Price: 12, Stock: 5, Version: product.Version}
+
+	assertStatus(t, updateProduct(t, admin, product, request), http.StatusOK)
+
+	// Второй администратор отправляет изменения, основанные на уже устаревшей версии
+	request.Price = 20
+	assertErrorCode(t, updateProduct(t, admin, product, request), http.StatusConflict, models.ErrCodeConflict)
+	if got := reloadProduct(t, product.ID); got.Price != 12 || got.Version != product.Version+1 {
+		t.Fatalf("price/version = %v/%d, want 12/%d", got.Price, got.Version, product.Version+1)
+	}
+}
+
+func TestUpdateProductIgnoresServerManagedFields(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	product := createProduct(t, 10, 5)
+	services.DB.Model(&product).Update("rating", 4.5)
+
+	recorder := updateProduct(t, admin, product, map[string]interface{}{
+		"id": product.ID + 100, "name": "Renamed", "price": 12, "stock": 5, "version": product.Version,
+		"rating": 1, "review_count": 99,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+
+	got := reloadProduct(t, product.ID)
+	if got.Name != "Renamed" || got.Rating != 4.5 {
+		t.Fatalf("name/rating = %s/%v, want Renamed/4.5", got.Name, got.Rating)
+	}
+}
+
+func TestCreateProductRejectsServerManagedFields(t *testing.T) {
+	admin := models.User{ID: 1, Role: models.RoleAdmin}
+
+	recorder := perform(t, CreateProduct, testRequest{
+		method: http.MethodPost, route: "/products", target: "/products", user: &admin,
+		body: map[string]interface{}{
+			"name": "Milk", "category_id": services.UncategorizedCategoryID, "price": 1.5, "currency": "USD", "rating": 5,
+		},
+	})
+	assertErrorCode(t, recorder, http.StatusBadRequest, models.ErrCodeValidationFailed)
+}
+
+func TestProductSearchMatchesDescription(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	byName := createProduct(t, 10, 5)
+	byDescription := createProduct(t, 20, 5)
+	other := createProduct(t, 30, 5)
+	services.DB.Model(&byName).Updates(map[string]interface{}{"name": "Oat milk", "description": "Plant based"})
+	services.DB.Model(&byDescription).Updates(map[string]interface{}{"name": "Latte", "description": "Espresso with milk"})
+	services.DB.Model(&other).Updates(map[string]interface{}{"name": "Bread", "description": "Rye"})
+
+	recorder := perform(t, GetProductsWithTimeout, testRequest{
+		method: http.MethodGet, route: "/products", target: "/products?name=MILK", user: &user,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	var response models.ProductResponse
+	decodeBody(t, recorder, &response)
+
+	if len(response.Data) != 2 {
+		t.Fatalf("found %d products, want 2", len(response.Data))
+	}
+	want := map[int]models.SearchMatch{
+		byName.ID:        {Field: "name", Start: 4, End: 8},
+		byDescription.ID: {Field: "description", Start: 14, End: 18},
+	}
+	for _, product := range response.Data {
+		if len(product.Matches) != 1 || product.Matches[0] != want[product.ID] {
+			t.Fatalf("product %d matches = %+v, want [%+v]", product.ID, product.Matches, want[product.ID])
+		}
+	}
+	if bytes.Contains(recorder.Body.Bytes(), []byte("Espresso")) {
+		t.Fatal("description leaked into the product list")
+	}
+}
+
+func priceRange(t *testing.T, user models.User, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, GetProductsByPriceRange, testRequest{
+		method: http.MethodGet, route: "/products/price-range", target: "/products/price-range?" + query, user: &user,
+	})
+}
+
+func TestGetProductsByPriceRangeRejectsInvertedRange(t *testing.T) {
+	user := models.User{ID: 1, Role: models.RoleUser}
+	assertErrorCode(t, priceRange(t, user, "minPrice=20&maxPrice=10"), http.StatusBadRequest, models.ErrCodeValidationFailed)
+	assertErrorCode(t, priceRange(t, user, "minPrice=abc&maxPrice=10"), http.StatusBadRequest, models.ErrCodeInvalidRequest)
+}
+
+func TestGetProductsByPriceRange(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	cheap := createProduct(t, 5, 1)
+	createProduct(t, 50, 1)
+	middle := createProduct(t, 15, 1)
+
+	recorder := priceRange(t, user, "minPrice=1&maxPrice=20&sort=price&order=desc")
+	assertStatus(t, recorder, http.StatusOK)
+	var response models.ProductResponse
+	decodeBody(t, recorder, &response)
+	if len(response.Data) != 2 || response.Data[0].ID != middle.ID || response.Data[1].ID != cheap.ID {
+		t.Fatalf("products = %+v, want products %d and %d by price desc", response.Data, middle.ID, cheap.ID)
+	}
+
+	// Пустой диапазон — это пустой список, а не отсутствующий ресурс
+	recorder = priceRange(t, user, "minPrice=100&maxPrice=200")
+	assertStatus(t, recorder, http.StatusOK)
+	decodeBody(t, recorder, &response)
+	if len(response.Data) != 0 || response.Total != 0 {
+		t.Fatalf("empty range returned %d products, total %d", len(response.Data), response.Total)
+	}
+}
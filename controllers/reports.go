@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"project/models"
+	"project/services"
+	"project/utils"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetSalesReport godoc
+// @Summary Отчет о продажах
+// @Description Возвращает выручку, количество оформленных заказов и самые продаваемые продукты за период.
+// @Description Учитываются только оформленные заказы (статус completed); суммы пересчитываются в базовую валюту по текущим ценам продуктов.
+// @Description Выручка учитывает скидки купонов: скидка заказа распределяется между его позициями пропорционально их сумме.
+// @Tags reports
+// @Produce json
+// @Param Authorization header string false "Токен доступа администратора (JWT)"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param top query int false "Количество продуктов в рейтинге" default(10)
+// @Success 200 {object} models.SalesReportResponse "Сводка продаж"
+// @Failure 400 {object} models.ErrorResponse "Некорректные параметры"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/reports/sales [get]
+func GetSalesReport(c *gin.Context) {
+	from, to, err := utils.ParseDateRange(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil || top < 1 {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Invalid top value")
+		return
+	}
+	if top > utils.PageLimitsFor(c).Max {
+		top = utils.PageLimitsFor(c).Max
+	}
+
+	orders := requestDB(c).Model(&models.Order{}).Where("orders.status = ?", models.OrderStatusCompleted)
+	if from != nil {
+		orders = orders.Where("orders.created_at >= ?", *from)
+	}
+	if to != nil {
+		orders = orders.Where("orders.created_at <= ?", *to)
+	}
+
+	var orderCount int64
+	if err := orders.Session(&gorm.Session{}).Count(&orderCount).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error building sales report")
+		return
+	}
+
+	// Скидка считается по всему заказу, поэтому выбираем отдельные позиции вместе со скидкой их заказа
+	var lines []struct {
+		OrderID       int
+		DiscountType  string
+		DiscountValue float64
+		ProductID     int
+		Name          string
+		Currency      string
+		Quantity      int64
+		Revenue       float64
+	}
+	if err := orders.Session(&gorm.Session{}).
+		Select("orders.id AS order_id, orders.discount_type, orders.discount_value, products.id AS product_id, products.name, products.currency, order_products.quantity, order_products.quantity * " + orderLinePrice + " AS revenue").
+		Joins("JOIN order_products ON order_products.order_id = orders.id").
+		Joins("JOIN products ON products.id = order_products.product_id").
+		Joins("LEFT JOIN product_variants ON product_variants.id = order_products.variant_id").
+		Scan(&lines).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error building sales report")
+		return
+	}
+
+	subtotals := make(map[int]float64)
+	for _, line := range lines {
+		subtotals[line.OrderID] += line.Revenue
+	}
+
+	report := models.SalesReportResponse{
+		Currency:    services.AppConfig.BaseCurrency,
+		OrderCount:  orderCount,
+		TopProducts: []models.ProductSales{},
+	}
+	productIndex := make(map[int]int)
+	for _, line := range lines {
+		// Доля скидки позиции пропорциональна ее сумме, так что выручка позиций заказа в сумме равна его итогу
+		revenue := line.Revenue
+		if subtotal := subtotals[line.OrderID]; subtotal > 0 {
+			discount := models.CalculateDiscount(subtotal, line.DiscountType, line.DiscountValue)
+			revenue -= discount * line.Revenue / subtotal
+		}
+		revenue, err := services.ConvertPrice(revenue, line.Currency, report.Currency)
+		if err != nil {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error converting revenue")
+			return
+		}
+		report.TotalRevenue += revenue
+
+		i, ok := productIndex[line.ProductID]
+		if !ok {
+			i = len(report.TopProducts)
+			productIndex[line.ProductID] = i
+			report.TopProducts = append(report.TopProducts, models.ProductSales{
+				ProductID: line.ProductID,
+				Name:      line.Name,
+			})
+		}
+		report.TopProducts[i].QuantitySold += line.Quantity
+		report.TopProducts[i].Revenue += revenue
+	}
+	report.TotalRevenue = math.Round(report.TotalRevenue*100) / 100
+	for i := range report.TopProducts {
+		report.TopProducts[i].Revenue = math.Round(report.TopProducts[i].Revenue*100) / 100
+	}
+
+	sort.Slice(report.TopProducts, func(i, j int) bool {
+		a, b := report.TopProducts[i], report.TopProducts[j]
+		if a.QuantitySold != b.QuantitySold {
+			return a.QuantitySold > b.QuantitySold
+		}
+		return a.ProductID < b.ProductID
+	})
+	if len(report.TopProducts) > top {
+		report.TopProducts = report.TopProducts[:top]
+	}
+
+	c.JSON(http.StatusOK, report)
+}
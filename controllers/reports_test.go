@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+	"project/models"
+	"project/services"
+	"testing"
+)
+
+func TestSalesReportSubtractsOrderDiscounts(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	user := createUser(t, models.RoleUser)
+	protein := createProduct(t, 100, 10)
+	bar := createProduct(t, 50, 10)
+
+	percent := createOrder(t, user, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: protein.ID, Quantity: 1, PriceAtPurchase: 100},
+		models.OrderProduct{ProductID: bar.ID, Quantity: 2, PriceAtPurchase: 50})
+	fixed := createOrder(t, user, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: protein.ID, Quantity: 1, PriceAtPurchase: 100})
+	createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: bar.ID, Quantity: 5, PriceAtPurchase: 50})
+	services.DB.Model(&percent).Updates(map[string]interface{}{"discount_type": models.CouponTypePercent, "discount_value": 10})
+	services.DB.Model(&fixed).Updates(map[string]interface{}{"discount_type": models.CouponTypeFixed, "discount_value": 30})
+
+	recorder := perform(t, GetSalesReport, testRequest{
+		method: http.MethodGet, route: "/admin/reports/sales", target: "/admin/reports/sales", user: &admin,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	var report models.SalesReportResponse
+	decodeBody(t, recorder, &report)
+
+	// 200 - 10% и 100 - 30
+	if report.OrderCount != 2 || report.TotalRevenue != 250 {
+		t.Fatalf("orders/revenue = %d/%v, want 2/250", report.OrderCount, report.TotalRevenue)
+	}
+	want := map[int]models.ProductSales{
+		protein.ID: {ProductID: protein.ID, Name: protein.Name, QuantitySold: 2, Revenue: 160},
+		bar.ID:     {ProductID: bar.ID, Name: bar.Name, QuantitySold: 2, Revenue: 90},
+	}
+	if len(report.TopProducts) != len(want) {
+		t.Fatalf("top products = %+v, want %d products", report.TopProducts, len(want))
+	}
+	for _, sales := range report.TopProducts {
+		if sales != want[sales.ProductID] {
+			t.Fatalf("product sales = %+v, want %+v", sales, want[sales.ProductID])
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"project/services"
+	"sync"
+	"testing"
+	"time"
+)
+
+func createOrderRequest(t *testing.T, user models.User, lines ...models.ProductInOrder) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, CreateOrder, testRequest{
+		method: http.MethodPost, route: "/orders", target: "/orders", user: &user,
+		body: models.CreateOrderRequest{Products: lines},
+	})
+}
+
+func TestCreateOrderReservesStock(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+
+	recorder := createOrderRequest(t, user, models.ProductInOrder{ProductID: product.ID, Quantity: 2})
+	assertStatus(t, recorder, http.StatusCreated)
+	var order models.Order
+	decodeBody(t, recorder, &order)
+
+	got := reloadProduct(t, product.ID)
+	if got.Stock != 5 || got.Reserved != 2 || got.Available != 3 {
+		t.Fatalf("stock/reserved/available = %d/%d/%d, want 5/2/3", got.Stock, got.Reserved, got.Available)
+	}
+	var reservation models.StockReservation
+	if err := services.DB.Where("order_id = ? AND product_id = ?", order.ID, product.ID).First(&reservation).Error; err != nil {
+		t.Fatalf("reservation not recorded: %v", err)
+	}
+	if reservation.Quantity != 2 || !reservation.ExpiresAt.After(time.Now()) {
+		t.Fatalf("reservation = %+v, want 2 units expiring in the future", reservation)
+	}
+
+	// Больше доступного остатка зарезервировать нельзя
+	recorder = createOrderRequest(t, user, models.ProductInOrder{ProductID: product.ID, Quantity: 4})
+	assertErrorCode(t, recorder, http.StatusConflict, models.ErrCodeInsufficientStock)
+}
+
+func TestExpiredReservationIsReleased(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	fresh := createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: product.ID, Quantity: 1, PriceAtPurchase: 10})
+	stale := createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: product.ID, Quantity: 3, PriceAtPurchase: 10})
+	reserve(t, fresh, product, 1)
+	reserve(t, stale, product, 3)
+	services.DB.Model(&models.StockReservation{}).Where("order_id = ?", stale.ID).Update("expires_at", time.Now().Add(-time.Minute))
+
+	released, err := services.ReleaseExpiredReservations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if released != 1 {
+		t.Fatalf("released %d reservations, want 1", released)
+	}
+	if got := reloadProduct(t, product.ID); got.Stock != 5 || got.Reserved != 1 {
+		t.Fatalf("stock/reserved = %d/%d, want 5/1", got.Stock, got.Reserved)
+	}
+	var left int64
+	services.DB.Model(&models.StockReservation{}).Where("order_id = ?", stale.ID).Count(&left)
+	if left != 0 {
+		t.Fatal("expired reservation was not removed")
+	}
+}
+
+func TestConcurrentOrdersReserveLastUnitOnce(t *testing.T) {
+	setupDB(t)
+	product := createProduct(t, 10, 1)
+	users := []models.User{createUser(t, models.RoleUser), createUser(t, models.RoleUser)}
+
+	recorders := make([]*httptest.ResponseRecorder, len(users))
+	var wg sync.WaitGroup
+	for i := range users {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorders[i] = createOrderRequest(t, users[i], models.ProductInOrder{ProductID: product.ID, Quantity: 1})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, recorder := range recorders {
+		if recorder.Code == http.StatusCreated {
+			created++
+			continue
+		}
+		assertErrorCode(t, recorder, http.StatusConflict, models.ErrCodeInsufficientStock)
+	}
+	if created != 1 {
+		t.Fatalf("%d orders reserved the last unit, want 1", created)
+	}
+	if got := reloadProduct(t, product.ID); got.Reserved != 1 {
+		t.Fatalf("reserved = %d, want 1", got.Reserved)
+	}
+}
+
+func TestProductListReportsAvailableStock(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	assertStatus(t, createOrderRequest(t, user, models.ProductInOrder{ProductID: product.ID, Quantity: 2}), http.StatusCreated)
+
+	recorder := perform(t, GetProductsWithTimeout, testRequest{
+		method: http.MethodGet, route: "/products", target: "/products", user: &user,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	var response models.ProductResponse
+	decodeBody(t, recorder, &response)
+	if len(response.Data) != 1 || response.Data[0].Available != 3 {
+		t.Fatalf("products = %+v, want one product with 3 available", response.Data)
+	}
+}
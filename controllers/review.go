@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"project/models"
@@ -9,20 +10,24 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateReview godoc
 // @Summary Создание нового отзыва
-// @Description Создает новый отзыв.
+// @Description Создает новый отзыв. Текст отзыва обрезается по краям и ограничен REVIEW_MAX_LENGTH символами. Отзыв помечается как подтвержденная покупка, если пользователь заказывал продукт. При включенной настройке REQUIRE_VERIFIED_PURCHASE отзыв без покупки запрещен. Число отзывов одного пользователя ограничено REVIEW_RATE_LIMIT за REVIEW_RATE_WINDOW.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param Authorization header string false "JWT токен пользователя"
 // @Param id path string true "ID продукта"
 // @Param request body models.CreateReviewRequest true "Данные для создания отзыва"
-// @Success 200 {object} models.MessageResponse "Отзыв успешно создан"
+// @Success 201 {object} models.MessageResponse "Отзыв успешно создан"
+// @Header 201 {string} Location "Адрес созданного отзыва"
 // @Failure 400 {object} models.ErrorResponse "Некорректные данные запроса"
 // @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 403 {object} models.ErrorResponse "Продукт не был куплен пользователем"
+// @Failure 429 {object} models.ErrorResponse "Слишком много отзывов, повторите после Retry-After секунд"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /products/{id}/reviews [post]
@@ -30,115 +35,322 @@ func CreateReview(c *gin.Context) {
 	productIDParam := c.Param("id")
 	productID, err := strconv.Atoi(productIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid product ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid product ID")
 		return
 	}
 
 	var product models.Product
 
-	if err := services.DB.Where("id = ?", productID).First(&product).Error; err != nil {
-		utils.HandleError(c, http.StatusBadRequest, fmt.Sprintf("Product with ID %d not found", productID))
+	if err := requestDB(c).Where("id = ?", productID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeProductNotFound, fmt.Sprintf("Product with ID %d not found", productID))
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching product")
 		return
 	}
 
 	var request models.CreateReviewRequest
 
-	if err := c.BindJSON(&request); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request data")
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
-	if request.Rating > 5 || request.Rating < 1 {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid rating")
+	reviewText, err := utils.NormalizeReviewText(request.ReviewText, request.Rating, services.AppConfig.ReviewMaxLength)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unathorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unathorized")
 		return
 	}
 
 	var existingReview models.Review
 
-	if err := services.DB.Where("product_id = ? AND user_id = ?", productID, userID).First(&existingReview).Error; err == nil {
-		utils.HandleError(c, http.StatusBadRequest, "You already have review")
+	if err := requestDB(c).Where("product_id = ? AND user_id = ?", productID, userID).First(&existingReview).Error; err == nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeAlreadyExists, "You already have review")
+		return
+	}
+
+	// Проверяем, заказывал ли пользователь этот продукт
+	var purchases int64
+	if err := requestDB(c).Model(&models.OrderProduct{}).
+		Joins("JOIN orders ON orders.id = order_products.order_id").
+		Where("orders.user_id = ? AND order_products.product_id = ?", userID, productID).
+		Count(&purchases).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error checking purchase history")
+		return
+	}
+
+	verified := purchases > 0
+	if services.AppConfig.RequireVerifiedPurchase && !verified {
+		utils.HandleError(c, http.StatusForbidden, models.ErrCodePurchaseRequired, "You can only review products you have purchased")
 		return
 	}
 
 	review := models.Review{
-		ReviewText: request.ReviewText,
+		ReviewText: reviewText,
 		Rating:     request.Rating,
+		Verified:   verified,
 		UserID:     userID.(int),
 		ProductID:  productID,
 	}
 
-	tx := services.DB.Begin()
+	tx := requestDB(c).Begin()
 
 	if tx.Error != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error starting transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
 		return
 	}
 
 	if err := tx.Create(&review).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Error creating review")
+		if errors.Is(err, gorm.ErrCheckConstraintViolated) {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Field 'rating' must be between 1 and 5")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating review")
+		}
 		return
 	}
 
-	var newRating float64
-
-	if err := tx.Model(&models.Review{}).Select("AVG(rating) as rating").Group("product_id").Where("product_id = ?", productID).Scan(&newRating).Error; err != nil {
+	if err := updateProductRating(tx, productID); err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Error getting new rating")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating rating")
 		return
 	}
 
-	product.Rating = newRating
-
-	if err := tx.Save(&product).Error; err != nil {
-		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Error updating rating")
-	}
-
 	if err := tx.Commit().Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error committing transaction") //?
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction") //?
 		return
 	}
 
-	c.JSON(http.StatusOK, models.MessageResponse{
+	c.Header("Location", fmt.Sprintf("/products/%d/reviews/%d", productID, review.ID))
+	c.JSON(http.StatusCreated, models.MessageResponse{
 		Message: fmt.Sprintf("Review created successfully. Review ID: %d", review.ID),
 	})
 }
 
+// updateProductRating пересчитывает рейтинг продукта как среднюю оценку его отзывов; без отзывов рейтинг равен нулю
+func updateProductRating(tx *gorm.DB, productID int) error {
+	return tx.Model(&models.Product{}).
+		Where("id = ?", productID).
+		Update("rating", gorm.Expr("(SELECT COALESCE(AVG(rating), 0) FROM reviews WHERE product_id = ?)", productID)).Error
+}
+
+// GetProductReviews godoc
 // @Summary Получение отзывов продукта
-// @Description Get all reviews for a specific product
+// @Description Публичный эндпоинт, авторизация не требуется. Возвращает отзывы о продукте с именами авторов и сводкой: средняя оценка и количество отзывов. Если передан валидный токен, у отзывов текущего пользователя is_mine = true
 // @Tags products
+// @Produce json
 // @Param Authorization header string false "JWT токен пользователя"
-// @Param id path int true "Product ID"
-// @Success 200 {object} []models.Review
-// @Failure 400 {object} models.MessageResponse
-// @Failure 500 {object} models.MessageResponse
-// @Security BearerAuth
+// @Param id path int true "ID продукта"
+// @Success 200 {object} models.ProductReviewsResponse "Сводка и список отзывов"
+// @Failure 400 {object} models.ErrorResponse "Некорректный ID продукта"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Router /products/{id}/reviews [get]
 func GetProductReviews(c *gin.Context) {
 	// Получаем идентификатор товара из параметров запроса
 	productIDParam := c.Param("id")
 	productID, err := strconv.Atoi(productIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid product ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid product ID")
+		return
+	}
+
+	// Запрашиваем отзывы вместе с именами авторов
+	reviews := []models.ReviewResponse{}
+	if err := requestDB(c).Model(&models.Review{}).
+		Select("reviews.id, reviews.review_text, reviews.rating, reviews.verified, reviews.user_id, users.username, reviews.product_id").
+		Joins("LEFT JOIN users ON users.id = reviews.user_id").
+		Where("reviews.product_id = ?", productID).
+		Order("reviews.id asc").
+		Scan(&reviews).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching reviews")
+		return
+	}
+	markOwnReviews(c, reviews)
+
+	var summary models.ReviewSummary
+	if err := requestDB(c).Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average_rating, COUNT(*) AS review_count").
+		Where("product_id = ?", productID).
+		Scan(&summary).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching review summary")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProductReviewsResponse{
+		Summary: summary,
+		Reviews: reviews,
+	})
+}
+
+// GetReviewByID godoc
+// @Summary Получение отзыва по ID
+// @Description Публичный эндпоинт, авторизация не требуется. Возвращает отзыв с именем автора, если он относится к указанному продукту. Если передан валидный токен, is_mine показывает, принадлежит ли отзыв текущему пользователю.
+// @Tags products
+// @Produce json
+// @Param Authorization header string false "JWT токен пользователя"
+// @Param id path int true "ID продукта"
+// @Param review_id path int true "ID отзыва"
+// @Success 200 {object} models.ReviewResponse "Отзыв"
+// @Failure 400 {object} models.ErrorResponse "Некорректный ID"
+// @Failure 404 {object} models.ErrorResponse "Отзыв не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /products/{id}/reviews/{review_id} [get]
+func GetReviewByID(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid product ID")
 		return
 	}
 
-	// Массив для хранения отзывов
-	var reviews []models.Review
+	reviewID, err := strconv.Atoi(c.Param("review_id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid review ID")
+		return
+	}
+
+	var reviews []models.ReviewResponse
+	if err := requestDB(c).Model(&models.Review{}).
+		Select("reviews.id, reviews.review_text, reviews.rating, reviews.verified, reviews.user_id, users.username, reviews.product_id").
+		Joins("LEFT JOIN users ON users.id = reviews.user_id").
+		Where("reviews.id = ? AND reviews.product_id = ?", reviewID, productID).
+		Limit(1).
+		Scan(&reviews).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching review")
+		return
+	}
 
-	// Запрашиваем отзывы из базы данных
-	if err := services.DB.Where("product_id = ?", productID).Find(&reviews).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error fetching reviews")
+	if len(reviews) == 0 {
+		utils.HandleError(c, http.StatusNotFound, models.ErrCodeReviewNotFound, "Review not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, reviews)
+	markOwnReviews(c, reviews)
+	c.JSON(http.StatusOK, reviews[0])
+}
+
+// markOwnReviews отмечает отзывы, принадлежащие авторизованному пользователю
+func markOwnReviews(c *gin.Context, reviews []models.ReviewResponse) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return
+	}
+	for i := range reviews {
+		reviews[i].IsMine = reviews[i].UserID == userID.(int)
+	}
+}
+
+// GetMyReviews godoc
+// @Summary Получение отзывов текущего пользователя
+// @Description Возвращает отзывы, оставленные текущим пользователем, с названиями продуктов, с пагинацией
+// @Tags users
+// @Produce json
+// @Param Authorization header string false "JWT токен пользователя"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Success 200 {object} models.UserReviewsResponse "Список отзывов пользователя"
+// @Failure 400 {object} models.ErrorResponse "Некорректные параметры пагинации"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /users/me/reviews [get]
+func GetMyReviews(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	var total int64
+	if err := requestDB(c).Model(&models.Review{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching reviews")
+		return
+	}
+
+	reviews := []models.UserReviewResponse{}
+	if err := requestDB(c).Model(&models.Review{}).
+		Select("reviews.id, reviews.review_text, reviews.rating, reviews.verified, reviews.product_id, products.name AS product_name").
+		Joins("LEFT JOIN products ON products.id = reviews.product_id").
+		Where("reviews.user_id = ?", userID).
+		Order("reviews.id desc").
+		Limit(limitInt).
+		Offset((pageInt - 1) * limitInt).
+		Scan(&reviews).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching reviews")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserReviewsResponse{
+		Data:       reviews,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
+	})
+}
+
+// GetMyReviewableProducts godoc
+// @Summary Продукты, на которые пользователь может оставить отзыв
+// @Description Возвращает продукты из заказов текущего пользователя (кроме отмененных), на которые он еще не оставил отзыв
+// @Tags users
+// @Produce json
+// @Param Authorization header string false "Токен доступа пользователя (JWT)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Success 200 {object} models.ProductResponse "Продукты без отзыва пользователя"
+// @Failure 400 {object} models.ErrorResponse "Некорректные параметры пагинации"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /users/me/reviewable [get]
+func GetMyReviewableProducts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	query := requestDB(c).Model(&models.Product{}).
+		Where(`products.id IN (SELECT order_products.product_id FROM order_products
+			JOIN orders ON orders.id = order_products.order_id
+			WHERE orders.user_id = ? AND orders.status <> ?)`, userID, models.OrderStatusCancelled).
+		Where("NOT EXISTS (SELECT 1 FROM reviews WHERE reviews.product_id = products.id AND reviews.user_id = ?)", userID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching products")
+		return
+	}
+
+	products := []models.ProductSummary{}
+	if err := query.
+		Select(productSummaryColumns).
+		Order("products.id asc").
+		Limit(limitInt).
+		Offset((pageInt - 1) * limitInt).
+		Find(&products).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching products")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProductResponse{
+		Data:       products,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
+	})
 }
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"project/middlewares"
+	"project/models"
+	"project/services"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReviewableProductsExcludeReviewed(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	other := createUser(t, models.RoleUser)
+	reviewed := createProduct(t, 10, 5)
+	first := createProduct(t, 20, 5)
+	second := createProduct(t, 30, 5)
+	reviewedByOther := createProduct(t, 40, 5)
+
+	createOrder(t, user, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: reviewed.ID, Quantity: 1, PriceAtPurchase: 10},
+		models.OrderProduct{ProductID: first.ID, Quantity: 1, PriceAtPurchase: 20})
+	createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: second.ID, Quantity: 1, PriceAtPurchase: 30})
+	createOrder(t, other, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: reviewedByOther.ID, Quantity: 1, PriceAtPurchase: 40})
+	createReview(t, user, reviewed, 4)
+	createReview(t, other, first, 2)
+
+	recorder := perform(t, GetMyReviewableProducts, testRequest{
+		method: http.MethodGet, route: "/users/me/reviewable", target: "/users/me/reviewable", user: &user,
+	})
+	assertStatus(t, recorder, http.StatusOK)
+	var response models.ProductResponse
+	decodeBody(t, recorder, &response)
+
+	if response.Total != 2 || len(response.Data) != 2 {
+		t.Fatalf("reviewable = %+v (total %d), want 2 products", response.Data, response.Total)
+	}
+	if response.Data[0].ID != first.ID || response.Data[1].ID != second.ID {
+		t.Fatalf("reviewable IDs = %d, %d; want %d, %d", response.Data[0].ID, response.Data[1].ID, first.ID, second.ID)
+	}
+}
+
+func TestCreateReviewRateLimit(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	other := createUser(t, models.RoleUser)
+	products := []models.Product{createProduct(t, 10, 5), createProduct(t, 20, 5), createProduct(t, 30, 5)}
+	for _, buyer := range []models.User{user, other} {
+		for _, product := range products {
+			createOrder(t, buyer, models.OrderStatusCompleted,
+				models.OrderProduct{ProductID: product.ID, Quantity: 1, PriceAtPurchase: product.Price})
+		}
+	}
+
+	// Лимит подключается так же, как в cmd/main.go; пользователь подставляется вместо AuthMiddleware
+	router := gin.New()
+	router.POST("/products/:id/reviews", func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.GetHeader("X-Test-User"))
+		c.Set("user_id", id)
+		c.Set("role", models.RoleUser)
+		c.Next()
+	}, middlewares.UserRateLimitMiddleware(2, time.Hour, "too many reviews, try again later"), CreateReview)
+	postReview := func(author models.User, product models.Product) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/products/%d/reviews", product.ID),
+			strings.NewReader(`{"rating":5,"review_text":"Good"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Test-User", strconv.Itoa(author.ID))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assertStatus(t, postReview(user, products[0]), http.StatusCreated)
+	assertStatus(t, postReview(user, products[1]), http.StatusCreated)
+
+	recorder := postReview(user, products[2])
+	assertErrorCode(t, recorder, http.StatusTooManyRequests, models.ErrCodeRateLimited)
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Fatal("Retry-After header is missing")
+	}
+	var reviews int64
+	services.DB.Model(&models.Review{}).Where("user_id = ?", user.ID).Count(&reviews)
+	if reviews != 2 {
+		t.Fatalf("%d reviews stored, want 2", reviews)
+	}
+
+	// Лимит одного пользователя не мешает другим
+	assertStatus(t, postReview(other, products[2]), http.StatusCreated)
+}
+
+func TestCreateReviewProductLookupErrorIsNotReportedAsNotFound(t *testing.T) {
+	useUnreachableDB(t)
+	user := models.User{ID: 1, Role: models.RoleUser}
+
+	recorder := perform(t, CreateReview, testRequest{
+		method: http.MethodPost, route: "/products/:id/reviews", target: "/products/1/reviews", user: &user,
+		body: map[string]interface{}{"rating": 5},
+	})
+	assertErrorCode(t, recorder, http.StatusInternalServerError, models.ErrCodeInternal)
+}
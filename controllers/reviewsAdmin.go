@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project/models"
+	"project/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetAllReviews godoc
+// @Summary Получение списка всех отзывов
+// @Description Возвращает отзывы по всем продуктам для модерации, начиная с новых, с пагинацией и фильтрами по продукту, автору и оценке
+// @Tags products
+// @Produce json
+// @Param Authorization header string false "Токен доступа пользователя (JWT)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Param product_id query int false "ID продукта"
+// @Param user_id query int false "ID автора отзыва"
+// @Param min_rating query int false "Минимальная оценка"
+// @Param max_rating query int false "Максимальная оценка"
+// @Success 200 {object} models.AdminReviewsResponse "Список отзывов"
+// @Failure 400 {object} models.ErrorResponse "Некорректные параметры запроса"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/reviews [get]
+func GetAllReviews(c *gin.Context) {
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	query := requestDB(c).Model(&models.Review{})
+
+	for _, column := range []string{"product_id", "user_id"} {
+		if raw := c.Query(column); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Invalid "+column)
+				return
+			}
+			query = query.Where("reviews."+column+" = ?", value)
+		}
+	}
+
+	minRating, maxRating := 1, 5
+	if raw := c.Query("min_rating"); raw != "" {
+		if minRating, err = strconv.Atoi(raw); err != nil || minRating < 1 || minRating > 5 {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "min_rating must be between 1 and 5")
+			return
+		}
+	}
+	if raw := c.Query("max_rating"); raw != "" {
+		if maxRating, err = strconv.Atoi(raw); err != nil || maxRating < 1 || maxRating > 5 {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "max_rating must be between 1 and 5")
+			return
+		}
+	}
+	if minRating > maxRating {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "min_rating must not be greater than max_rating")
+		return
+	}
+	query = query.Where("reviews.rating BETWEEN ? AND ?", minRating, maxRating)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching reviews")
+		return
+	}
+
+	reviews := []models.ReviewResponse{}
+	if err := query.
+		Select("reviews.id, reviews.review_text, reviews.rating, reviews.verified, reviews.user_id, users.username, reviews.product_id").
+		Joins("LEFT JOIN users ON users.id = reviews.user_id").
+		Order("reviews.id desc").
+		Limit(limitInt).
+		Offset((pageInt - 1) * limitInt).
+		Scan(&reviews).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching reviews")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AdminReviewsResponse{
+		Data:       reviews,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
+	})
+}
+
+// DeleteReviewAdmin godoc
+// @Summary Удаление отзыва администратором
+// @Description Удаляет любой отзыв и пересчитывает рейтинг продукта по оставшимся отзывам
+// @Tags products
+// @Produce json
+// @Param Authorization header string false "Токен доступа пользователя (JWT)"
+// @Param id path int true "ID отзыва"
+// @Success 200 {object} models.MessageResponse "Отзыв удален"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Отзыв не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/reviews/{id} [delete]
+func DeleteReviewAdmin(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid review ID")
+		return
+	}
+
+	var review models.Review
+	if err := requestDB(c).First(&review, reviewID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeReviewNotFound, "Review not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching review")
+		}
+		return
+	}
+
+	tx := requestDB(c).Begin()
+
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error starting transaction")
+		return
+	}
+
+	if err := tx.Delete(&review).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting review")
+		return
+	}
+
+	if err := updateProductRating(tx, review.ProductID); err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating rating")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error committing transaction")
+		return
+	}
+	recordAudit(c, models.AuditActionReviewDelete, fmt.Sprintf("review:%d", review.ID), models.StringMap{
+		"product_id": strconv.Itoa(review.ProductID),
+		"user_id":    strconv.Itoa(review.UserID),
+		"rating":     strconv.Itoa(review.Rating),
+	})
+
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "Review deleted",
+	})
+}
@@ -1,19 +1,26 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"project/models"
 	"project/services"
 	"project/utils"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetUserInfo godoc
 // @Summary Получение информации о пользователе
-// @Description Получает информацию о текущем пользователе, включая его имя и роль. Пароль в ответе не передается.
+// @Description Получает информацию о текущем пользователе, включая его имя, email и роль. Пароль в ответе не передается.
 // @Tags users
 // @Accept  json
 // @Produce  json
@@ -26,27 +33,152 @@ import (
 func GetUserInfo(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	var user models.User
-	if err := services.DB.First(&user, userID).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "User not found")
+	if err := requestDB(c).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
 		return
 	}
 
 	userInfoResponse := models.UserInfoResponse{
-		Name: user.Username,
-		Role: user.Role,
+		Name:  user.Username,
+		Email: user.Email,
+		Role:  user.Role,
 	}
 
 	c.JSON(http.StatusOK, userInfoResponse)
 }
 
+// GetMyStats godoc
+// @Summary Статистика текущего пользователя
+// @Description Возвращает количество заказов и отзывов пользователя и общую сумму оформленных заказов (статус completed) с учетом скидок. У нового пользователя все значения нулевые.
+// @Tags users
+// @Produce json
+// @Param        Authorization  header  string  false  "Токен пользователя"
+// @Success 200 {object} models.UserStatsResponse "Статистика пользователя"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /users/me/stats [get]
+func GetMyStats(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var stats models.UserStatsResponse
+	if err := requestDB(c).Model(&models.Order{}).Where("user_id = ?", userID).Count(&stats.OrderCount).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user stats")
+		return
+	}
+	if err := requestDB(c).Model(&models.Review{}).Where("user_id = ?", userID).Count(&stats.ReviewCount).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user stats")
+		return
+	}
+
+	// Скидка зависит от суммы каждого заказа, поэтому суммы считаются по заказам, а скидки применяются здесь
+	var orders []struct {
+		Subtotal      float64
+		DiscountType  string
+		DiscountValue float64
+	}
+	if err := requestDB(c).Model(&models.Order{}).
+		Select("COALESCE(SUM(order_products.quantity * "+orderLinePrice+"), 0) AS subtotal, orders.discount_type, orders.discount_value").
+		Joins("JOIN order_products ON order_products.order_id = orders.id").
+		Joins("JOIN products ON products.id = order_products.product_id").
+		Joins("LEFT JOIN product_variants ON product_variants.id = order_products.variant_id").
+		Where("orders.user_id = ? AND orders.status = ?", userID, models.OrderStatusCompleted).
+		Group("orders.id, orders.discount_type, orders.discount_value").
+		Scan(&orders).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user stats")
+		return
+	}
+	for _, order := range orders {
+		stats.TotalSpent += order.Subtotal - models.CalculateDiscount(order.Subtotal, order.DiscountType, order.DiscountValue)
+	}
+	stats.TotalSpent = math.Round(stats.TotalSpent*100) / 100
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ExportMyData godoc
+// @Summary Выгрузка данных пользователя
+// @Description Возвращает JSON-файл со всеми данными текущего пользователя: профиль без пароля, заказы с позициями и отзывы.
+// @Tags users
+// @Produce json
+// @Param        Authorization  header  string  false  "Токен пользователя"
+// @Success 200 {object} models.UserDataExport "Файл с данными пользователя"
+// @Header 200 {string} Content-Disposition "attachment; filename=user-{id}-data.json"
+// @Failure 401 {object} models.ErrorResponse "Неавторизованный доступ"
+// @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /users/me/export [get]
+func ExportMyData(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var user models.User
+	if err := requestDB(c).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
+		return
+	}
+
+	orders := []models.Order{}
+	if err := requestDB(c).Scopes(withOrderProducts).
+		Where("user_id = ?", user.ID).
+		Order("created_at asc, id asc").
+		Find(&orders).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching orders")
+		return
+	}
+
+	reviews := []models.UserReviewResponse{}
+	if err := requestDB(c).Model(&models.Review{}).
+		Select("reviews.id, reviews.review_text, reviews.rating, reviews.verified, reviews.product_id, products.name AS product_name").
+		Joins("LEFT JOIN products ON products.id = reviews.product_id").
+		Where("reviews.user_id = ?", user.ID).
+		Order("reviews.id asc").
+		Scan(&reviews).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching reviews")
+		return
+	}
+
+	export := models.UserDataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    user.Profile(),
+		Orders:     orders,
+		Reviews:    reviews,
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-data.json"`, user.ID))
+	c.Status(http.StatusOK)
+
+	// Данные уже собраны, поэтому ошибка записи означает обрыв соединения
+	if err := json.NewEncoder(c.Writer).Encode(export); err != nil {
+		log.Println("Error writing user data export:", err)
+	}
+}
+
 // UpdateUserName godoc
 // @Summary Обновление имени пользователя
-// @Description Позволяет авторизованному пользователю обновить свое имя
+// @Description Позволяет авторизованному пользователю обновить свое имя. Имя приводится к нижнему регистру и должно быть уникальным без учета регистра.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -63,42 +195,110 @@ func GetUserInfo(c *gin.Context) {
 func UpdateUserName(c *gin.Context) {
 	var request models.UpdateUsernameRequest
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request data")
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	username := utils.NormalizeUsername(request.Username)
+	if len(username) < 2 {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Username length is less than 2")
 		return
 	}
 
 	var existingUser models.User
-	if err := services.DB.Where("username = ?", request.Username).First(&existingUser).Error; err == nil {
-		utils.HandleError(c, http.StatusConflict, "Username already taken")
+	if err := requestDB(c).Where("LOWER(username) = ? AND id <> ?", username, userID).First(&existingUser).Error; err == nil {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Username already taken")
 		return
 	}
 
 	var user models.User
-	if err := services.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "User not found")
+	if err := requestDB(c).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
 		return
 	}
 
-	if len(request.Username) < 2 {
-		utils.HandleError(c, http.StatusBadRequest, "Username length is less then 2")
+	user.Username = username
+	if err := requestDB(c).Save(&user).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating user name")
 		return
 	}
 
-	user.Username = request.Username
-	if err := services.DB.Save(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error updating user name")
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "User name updated successfully",
+	})
+}
+
+// UpdateUserEmail godoc
+// @Summary Обновление email пользователя
+// @Description Позволяет авторизованному пользователю обновить свой email
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Токен авторизации"
+// @Param request body models.UpdateEmailRequest true "Данные для обновления email"
+// @Success 200 {object} models.MessageResponse "Email успешно обновлен"
+// @Failure 400 {object} models.ErrorResponse "Некорректные данные запроса"
+// @Failure 401 {object} models.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} models.ErrorResponse "Email уже занят"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /users/me/email [patch]
+func UpdateUserEmail(c *gin.Context) {
+	var request models.UpdateEmailRequest
+
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	email := utils.NormalizeEmail(request.Email)
+	if !utils.IsValidEmail(email) {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, "Invalid email format")
+		return
+	}
+
+	var existingUser models.User
+	if err := requestDB(c).Where("email = ? AND id <> ?", email, userID).First(&existingUser).Error; err == nil {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "Email already taken")
+		return
+	}
+
+	var user models.User
+	if err := requestDB(c).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
+		return
+	}
+
+	user.Email = email
+	if err := requestDB(c).Save(&user).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating email")
 		return
 	}
 
 	c.JSON(http.StatusOK, models.MessageResponse{
-		Message: "User name updated successfully",
+		Message: "Email updated successfully",
 	})
 }
 
@@ -120,42 +320,46 @@ func UpdateUserName(c *gin.Context) {
 func UpdateUserPassword(c *gin.Context) {
 	var request models.UpdatePasswordRequest
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request data")
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	var user models.User
-	if err := services.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "User not found")
+	if err := requestDB(c).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
 		return
 	}
 
 	if !utils.CheckPassword(user.Password, request.OldPassword) {
-		utils.HandleError(c, http.StatusUnauthorized, "Old password is incorrect")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeInvalidCredentials, "Old password is incorrect")
 		return
 	}
 
-	if len(request.NewPassword) < 6 {
-		utils.HandleError(c, http.StatusBadRequest, "Password length is less than 6")
+	if err := utils.ValidatePassword(request.NewPassword); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	hashedPassword, err := utils.HashPassword(request.NewPassword)
 	if err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error hashing new password")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error hashing new password")
 		return
 	}
 
 	user.Password = hashedPassword
-	if err := services.DB.Save(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error updating password")
+	if err := requestDB(c).Save(&user).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating password")
 		return
 	}
 
@@ -165,75 +369,118 @@ func UpdateUserPassword(c *gin.Context) {
 }
 
 // UpdateUserRole godoc
-// @Summary Обновление роли пользователя на администратора
-// @Description Позволяет администратору изменить роль пользователя только с "user" на "admin"
+// @Summary Обновление роли пользователя
+// @Description Позволяет администратору назначить пользователю любую допустимую роль ("user" или "admin"). Нельзя понизить последнего активного администратора.
 // @Tags users
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string false "Токен авторизации"
 // @Param id path int true "ID пользователя"
 // @Param data body models.UpdateUserRoleRequest true "Данные для обновления роли"
-// @Success 200 {object} models.MessageResponse "Роль пользователя обновлена на администратора"
-// @Failure 400 {object} models.ErrorResponse "Некорректные данные запроса или обновление роли невозможно"
+// @Success 200 {object} models.MessageResponse "Роль пользователя обновлена"
+// @Failure 400 {object} models.ErrorResponse "Некорректные данные запроса, недопустимая роль или попытка изменить свою роль"
 // @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} models.ErrorResponse "Нельзя понизить последнего активного администратора"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
 // @Router /users/{id}/role [patch]
 func UpdateUserRole(c *gin.Context) {
 	var request models.UpdateUserRoleRequest
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid request data")
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	userIDParam := c.Param("id")
 	userID, err := strconv.Atoi(userIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid user ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid user ID")
+		return
+	}
+
+	// Администратор не может менять себя через административный эндпоинт
+	if currentUserID, _ := c.Get("user_id"); currentUserID == userID {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "You cannot change your own role")
+		return
+	}
+
+	if !models.ValidRoles[request.Role] {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, fmt.Sprintf("Invalid role '%s'", request.Role))
+		return
+	}
+
+	tx := requestDB(c).Begin()
+	if tx.Error != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start transaction")
+		return
+	}
+
+	// Строки активных администраторов блокируются в порядке id до чтения пользователя,
+	// чтобы параллельные понижения не сняли роль с последнего администратора
+	var adminIDs []int
+	if err := tx.Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ? AND active = ?", models.RoleAdmin, true).
+		Order("id").
+		Pluck("id", &adminIDs).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating user role")
 		return
 	}
 
 	// Проверка существования пользователя
 	var user models.User
-	if err := services.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "User not found")
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
 		return
 	}
 
-	// Ограничение изменения роли только с "user" на "admin"
-	if user.Role != "user" {
-		utils.HandleError(c, http.StatusBadRequest, "Role can only be updated from 'user' to 'admin'")
+	// Не даем понизить последнего активного администратора, иначе управлять системой будет некому
+	if user.Role == models.RoleAdmin && user.Active && request.Role != models.RoleAdmin && len(adminIDs) <= 1 {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeLastAdmin, "Cannot demote the last remaining admin")
 		return
 	}
 
-	if request.Role != "admin" {
-		utils.HandleError(c, http.StatusBadRequest, "Role can only be updated to 'admin'")
+	// Обновление роли пользователя
+	previousRole := user.Role
+	if err := tx.Model(&user).Update("role", request.Role).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating user role")
 		return
 	}
 
-	// Обновление роли пользователя
-	user.Role = "admin"
-	if err := services.DB.Save(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error updating user role")
+	if err := tx.Commit().Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating user role")
 		return
 	}
+	recordAudit(c, models.AuditActionUserRoleUpdate, fmt.Sprintf("user:%d", user.ID), models.StringMap{
+		"from": previousRole,
+		"to":   request.Role,
+	})
 
 	c.JSON(http.StatusOK, models.MessageResponse{
-		Message: "User role updated to admin successfully",
+		Message: fmt.Sprintf("User role updated to %s successfully", request.Role),
 	})
 }
 
 // DeleteUser godoc
-// @Summary Удаление пользователя с ролью "user"
-// @Description Позволяет администратору удалить только пользователя с ролью "user"
+// @Summary Деактивация или удаление пользователя с ролью "user"
+// @Description Позволяет администратору деактивировать пользователя с ролью "user". Заказы пользователя сохраняются. С параметром purge=true пользователь, его заказы, отзывы и ключи идемпотентности удаляются безвозвратно, а рейтинг продуктов с удаленными отзывами пересчитывается.
 // @Tags users
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string false "Токен авторизации"
 // @Param id path int true "ID пользователя"
-// @Success 200 {object} models.MessageResponse "Пользователь успешно удален"
-// @Failure 400 {object} models.ErrorResponse "Некорректные данные запроса или удаление невозможно"
+// @Param purge query bool false "Удалить пользователя и связанные данные безвозвратно"
+// @Success 200 {object} models.MessageResponse "Пользователь успешно деактивирован или удален"
+// @Failure 400 {object} models.ErrorResponse "Некорректные данные запроса, удаление невозможно или попытка удалить себя"
 // @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
@@ -242,53 +489,143 @@ func DeleteUser(c *gin.Context) {
 	userIDParam := c.Param("id")
 	userID, err := strconv.Atoi(userIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid user ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid user ID")
+		return
+	}
+
+	purge := false
+	if purgeParam := c.Query("purge"); purgeParam != "" {
+		purge, err = strconv.ParseBool(purgeParam)
+		if err != nil {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid purge value")
+			return
+		}
+	}
+
+	// Удалить свою учетную запись можно только через DeleteSelf
+	if currentUserID, _ := c.Get("user_id"); currentUserID == userID {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "You cannot delete yourself via this endpoint")
 		return
 	}
 
 	// Проверка существования пользователя
 	var user models.User
-	if err := services.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "User not found")
+	if err := requestDB(c).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
 		return
 	}
 
 	// Ограничение удаления только для пользователей с ролью "user"
 	if user.Role != "user" {
-		utils.HandleError(c, http.StatusBadRequest, "Only users with role 'user' can be deleted")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Only users with role 'user' can be deleted")
+		return
+	}
+
+	if !purge {
+		if err := deactivateUser(requestDB(c), user.ID); err != nil {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deactivating user")
+			return
+		}
+		recordAudit(c, models.AuditActionUserDeactivate, fmt.Sprintf("user:%d", user.ID), models.StringMap{
+			"username": user.Username,
+		})
+
+		c.JSON(http.StatusOK, models.MessageResponse{
+			Message: "User deactivated successfully",
+		})
 		return
 	}
 
-	tx := services.DB.Begin()
+	tx := requestDB(c).Begin()
 
 	if tx.Error != nil {
 		log.Println("Error starting transaction:", tx.Error)
-		utils.HandleError(c, http.StatusInternalServerError, "Failed to start transaction")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start transaction")
 		return
 	}
 
+	var orderIDs []int
+	if err := tx.Model(&models.Order{}).Where("user_id = ?", userID).Pluck("id", &orderIDs).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Internal server error")
+		return
+	}
+
+	for _, orderID := range orderIDs {
+		if err := services.ReleaseOrderReservations(tx, orderID); err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Internal server error")
+			return
+		}
+	}
+
 	if err := tx.Where("order_id IN (SELECT id FROM orders WHERE user_id = ?)", userID).Delete(&models.OrderProduct{}).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Internal server error")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Internal server error")
 		return
 	}
 
 	if err := tx.Where("user_id = ?", userID).Delete(&models.Order{}).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Internal sever error")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Internal sever error")
+		return
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Internal server error")
+		return
+	}
+
+	// Рейтинг продуктов, на которые пользователь оставил отзывы, пересчитывается без его оценок
+	var reviewedProductIDs []int
+	if err := tx.Model(&models.Review{}).Where("user_id = ?", userID).Distinct().Pluck("product_id", &reviewedProductIDs).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Internal server error")
+		return
+	}
+
+	deletedReviews := tx.Where("user_id = ?", userID).Delete(&models.Review{})
+	if deletedReviews.Error != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting reviews")
+		return
+	}
+
+	for _, productID := range reviewedProductIDs {
+		if err := updateProductRating(tx, productID); err != nil {
+			tx.Rollback()
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error updating rating")
+			return
+		}
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error; err != nil {
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Internal server error")
 		return
 	}
 
 	// Удаление пользователя
 	if err := tx.Delete(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting user")
+		tx.Rollback()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting user")
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting user and related data")
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deleting user and related data")
 		return
 	}
+	recordAudit(c, models.AuditActionUserDelete, fmt.Sprintf("user:%d", user.ID), models.StringMap{
+		"username":        user.Username,
+		"deleted_orders":  strconv.Itoa(len(orderIDs)),
+		"deleted_reviews": strconv.FormatInt(deletedReviews.RowsAffected, 10),
+	})
 
 	c.JSON(http.StatusOK, models.MessageResponse{
 		Message: "User and related data deleted successfully",
@@ -296,14 +633,16 @@ func DeleteUser(c *gin.Context) {
 }
 
 // DeleteSelf godoc
-// @Summary Удаление своей учетной записи
-// @Description Позволяет пользователю удалить свою учетную запись. Администраторы не могут удалять себя.
+// @Summary Деактивация своей учетной записи
+// @Description Позволяет пользователю деактивировать свою учетную запись после подтверждения текущим паролем. Войти в нее больше нельзя, но история заказов сохраняется. Администраторы не могут деактивировать себя.
 // @Tags users
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string false "Токен авторизации"
-// @Success 200 {object} models.MessageResponse "Учетная запись успешно удалена"
-// @Failure 401 {object} models.ErrorResponse "Пользователь не авторизован"
+// @Param request body models.DeleteSelfRequest true "Текущий пароль"
+// @Success 200 {object} models.MessageResponse "Учетная запись успешно деактивирована"
+// @Failure 400 {object} models.ErrorResponse "Пароль не передан"
+// @Failure 401 {object} models.ErrorResponse "Пользователь не авторизован или пароль неверен"
 // @Failure 403 {object} models.ErrorResponse "Администратор не может удалить себя"
 // @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
@@ -313,83 +652,219 @@ func DeleteSelf(c *gin.Context) {
 	// Получение идентификатора пользователя из контекста
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.HandleError(c, http.StatusUnauthorized, "Unauthorized")
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request models.DeleteSelfRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	// Проверяем, существует ли пользователь
 	var user models.User
-	if err := services.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "User not found")
+	if err := requestDB(c).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
 		return
 	}
 
 	// Администратор не может удалить себя
 	if user.Role == "admin" {
-		utils.HandleError(c, http.StatusForbidden, "Administrators cannot delete themselves")
+		utils.HandleError(c, http.StatusForbidden, models.ErrCodeForbidden, "Administrators cannot delete themselves")
+		return
+	}
+
+	// Одного токена недостаточно: украденный токен не должен позволять удалить учетную запись
+	if !utils.CheckPassword(user.Password, request.Password) {
+		utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeInvalidCredentials, "Invalid password")
+		return
+	}
+
+	if err := deactivateUser(requestDB(c), user.ID); err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error deactivating account")
 		return
 	}
 
-	tx := services.DB.Begin()
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "Your account has been deactivated successfully",
+	})
+}
 
+// deactivateUser снимает флаг активности и отзывает токены обновления пользователя
+func deactivateUser(db *gorm.DB, userID int) error {
+	tx := db.Begin()
 	if tx.Error != nil {
 		log.Println("Error starting transaction:", tx.Error)
-		utils.HandleError(c, http.StatusInternalServerError, "Failed to start transaction")
-		return
+		return tx.Error
 	}
 
-	if err := tx.Where("order_id IN (SELECT id FROM orders WHERE user_id = ?)", userID).Delete(&models.OrderProduct{}).Error; err != nil {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("active", false).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Internal server error")
-		return
+		return err
 	}
 
-	if err := tx.Where("user_id = ?", userID).Delete(&models.Order{}).Error; err != nil {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error; err != nil {
 		tx.Rollback()
-		utils.HandleError(c, http.StatusInternalServerError, "Internal sever error")
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// GetAllUsers godoc
+// @Summary Получение списка всех пользователей
+// @Description Возвращает данные пользователей с пагинацией и фильтрацией по имени и роли.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string false "Токен авторизации"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10)
+// @Param username query string false "Часть имени пользователя"
+// @Param role query string false "Роль пользователя"
+// @Success 200 {object} models.UserResponse "Список пользователей"
+// @Failure 400 {object} models.ErrorResponse "Некорректные параметры запроса"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /users [get]
+func GetAllUsers(c *gin.Context) {
+	var users []models.User
+	var total int64
+
+	pageInt, limitInt, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
 		return
 	}
+	offset := (pageInt - 1) * limitInt
 
-	// Удаление пользователя
-	if err := tx.Delete(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting user")
+	query := requestDB(c).Model(&models.User{})
+
+	if username := c.Query("username"); username != "" {
+		query = query.Where("username ILIKE ?", "%"+username+"%")
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error retrieving users")
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error deleting user and related data")
+	if err := query.Order("id asc").Limit(limitInt).Offset(offset).Find(&users).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error retrieving users")
 		return
 	}
-	c.JSON(http.StatusOK, models.MessageResponse{
-		Message: "Your account has been deleted successfully",
+
+	profiles := make([]models.UserProfile, len(users))
+	for i, user := range users {
+		profiles[i] = user.Profile()
+	}
+
+	c.JSON(http.StatusOK, models.UserResponse{
+		Data:       profiles,
+		Pagination: utils.NewPagination(c, total, pageInt, limitInt),
 	})
 }
 
-// GetAllUsers godoc
-// @Summary Получение списка всех пользователей
-// @Description Возвращает данные всех пользователей.
+// CreateUserAdmin godoc
+// @Summary Создание пользователя администратором
+// @Description Создает учетную запись с указанной ролью ("user" по умолчанию или "admin"). Пароль проверяется по действующей политике и сохраняется в виде хеша.
 // @Tags users
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string false "Токен авторизации"
-// @Success 200 {array} models.User "Список пользователей"
+// @Param data body models.CreateUserRequest true "Данные пользователя"
+// @Success 201 {object} models.UserProfile "Созданный пользователь без пароля"
+// @Header 201 {string} Location "Адрес созданного пользователя"
+// @Failure 400 {object} models.ErrorResponse "Некорректные данные запроса"
+// @Failure 409 {object} models.ErrorResponse "Имя пользователя или email уже заняты"
+// @Failure 422 {object} models.ErrorResponse "Ошибки в полях, перечисленные в fields"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Security BearerAuth
-// @Router /users [get]
-func GetAllUsers(c *gin.Context) {
-	var users []models.User
+// @Router /admin/users [post]
+func CreateUserAdmin(c *gin.Context) {
+	var request models.CreateUserRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	var fieldErrs utils.FieldErrors
+
+	username := utils.NormalizeUsername(request.Username)
+	if len(username) < 2 {
+		fieldErrs.Add("username", "Username length is less than 2")
+	}
+
+	if err := utils.ValidatePassword(request.Password); err != nil {
+		fieldErrs.Add("password", err.Error())
+	}
+
+	email := utils.NormalizeEmail(request.Email)
+	if !utils.IsValidEmail(email) {
+		fieldErrs.Add("email", "Invalid email format")
+	}
+
+	role := request.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	if !models.ValidRoles[role] {
+		fieldErrs.Add("role", fmt.Sprintf("Invalid role '%s'", role))
+	}
+
+	if len(fieldErrs) > 0 {
+		utils.HandleValidationError(c, fieldErrs)
+		return
+	}
+
+	var existingUser models.User
+	if err := requestDB(c).Where("LOWER(username) = ?", username).First(&existingUser).Error; err == nil {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "user already exists")
+		return
+	}
+
+	if err := requestDB(c).Where("email = ?", email).First(&existingUser).Error; err == nil {
+		utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "email already taken")
+		return
+	}
 
-	if err := services.DB.Find(&users).Error; err != nil {
-		utils.HandleError(c, http.StatusInternalServerError, "Error retrieving users")
+	hashedPassword, err := utils.HashPassword(request.Password)
+	if err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating user")
 		return
 	}
 
-	// Исключаем пароли из возвращаемых данных
-	for i := range users {
-		users[i].Password = ""
+	user := models.User{
+		Username: username,
+		Email:    email,
+		Password: hashedPassword,
+		Role:     role,
+		Active:   true,
+	}
+	if err := requestDB(c).Create(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			utils.HandleError(c, http.StatusConflict, models.ErrCodeAlreadyExists, "user already exists")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error creating user")
+		}
+		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	recordAudit(c, models.AuditActionUserCreate, fmt.Sprintf("user:%d", user.ID), models.StringMap{
+		"username": user.Username,
+		"role":     user.Role,
+	})
+
+	c.Header("Location", fmt.Sprintf("/users/%d", user.ID))
+	c.JSON(http.StatusCreated, user.Profile())
 }
 
 // GetUserByID godoc
@@ -400,7 +875,7 @@ func GetAllUsers(c *gin.Context) {
 // @Produce  json
 // @Param Authorization header string false "Токен авторизации"
 // @Param id path int true "ID пользователя"
-// @Success 200 {object} models.User "Данные пользователя"
+// @Success 200 {object} models.UserProfile "Данные пользователя"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
 // @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
@@ -410,18 +885,19 @@ func GetUserByID(c *gin.Context) {
 	userIDParam := c.Param("id")
 	userID, err := strconv.Atoi(userIDParam)
 	if err != nil {
-		utils.HandleError(c, http.StatusBadRequest, "Invalid user ID")
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid user ID")
 		return
 	}
 
 	var user models.User
-	if err := services.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		utils.HandleError(c, http.StatusNotFound, "User not found")
+	if err := requestDB(c).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeUserNotFound, "User not found")
+			return
+		}
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Error fetching user")
 		return
 	}
 
-	// Исключаем пароль из возвращаемых данных
-	user.Password = ""
-
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, user.Profile())
 }
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"project/services"
+	"project/utils"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "secret123"
+
+func setPassword(t *testing.T, user *models.User) {
+	t.Helper()
+	hashed, err := utils.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user.Password = hashed
+	if err := services.DB.Model(user).Update("password", hashed).Error; err != nil {
+		t.Fatalf("store password: %v", err)
+	}
+}
+
+func login(t *testing.T, user models.User) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, Login, testRequest{
+		method: http.MethodPost, route: "/login", target: "/login",
+		body: models.Credentials{Username: user.Username, Password: testPassword},
+	})
+}
+
+func deleteUser(t *testing.T, admin, user models.User, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, DeleteUser, testRequest{
+		method: http.MethodDelete, route: "/users/:id", target: fmt.Sprintf("/users/%d%s", user.ID, query), user: &admin,
+	})
+}
+
+func TestDeactivatedUserCannotLogin(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	user := createUser(t, models.RoleUser)
+	setPassword(t, &user)
+	assertStatus(t, login(t, user), http.StatusOK)
+
+	assertStatus(t, deleteUser(t, admin, user, ""), http.StatusOK)
+
+	assertErrorCode(t, login(t, user), http.StatusForbidden, models.ErrCodeAccountDeactivated)
+	var tokens int64
+	services.DB.Model(&models.RefreshToken{}).Where("user_id = ?", user.ID).Count(&tokens)
+	if tokens != 0 {
+		t.Fatalf("%d refresh tokens left after deactivation, want 0", tokens)
+	}
+}
+
+func TestPurgeUserRemovesReviewsAndIdempotencyKeys(t *testing.T) {
+	setupDB(t)
+	admin := createUser(t, models.RoleAdmin)
+	user := createUser(t, models.RoleUser)
+	other := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	createReview(t, user, product, 1)
+	createReview(t, other, product, 5)
+	if err := updateProductRating(services.DB, product.ID); err != nil {
+		t.Fatal(err)
+	}
+	order := createOrder(t, user, models.OrderStatusCompleted,
+		models.OrderProduct{ProductID: product.ID, Quantity: 1, PriceAtPurchase: 10})
+	services.DB.Create(&models.IdempotencyKey{UserID: user.ID, Key: "checkout-1", OrderID: order.ID})
+
+	assertStatus(t, deleteUser(t, admin, user, "?purge=true"), http.StatusOK)
+
+	for name, model := range map[string]interface{}{
+		"orders":           &models.Order{},
+		"reviews":          &models.Review{},
+		"idempotency keys": &models.IdempotencyKey{},
+	} {
+		var count int64
+		services.DB.Model(model).Where("user_id = ?", user.ID).Count(&count)
+		if count != 0 {
+			t.Fatalf("%d %s left after purge, want 0", count, name)
+		}
+	}
+	if got := reloadProduct(t, product.ID); got.Rating != 5 {
+		t.Fatalf("product rating = %v, want 5", got.Rating)
+	}
+	var reviews int64
+	services.DB.Model(&models.Review{}).Where("user_id = ?", other.ID).Count(&reviews)
+	if reviews != 1 {
+		t.Fatal("another user's review was deleted")
+	}
+}
+
+func updateRole(t *testing.T, admin, user models.User, role string) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, UpdateUserRole, testRequest{
+		method: http.MethodPatch, route: "/users/:id/role", target: fmt.Sprintf("/users/%d/role", user.ID), user: &admin,
+		body: models.UpdateUserRoleRequest{Role: role},
+	})
+}
+
+func TestUpdateUserRoleIgnoresInactiveAdmins(t *testing.T) {
+	setupDB(t)
+	inactive := createUser(t, models.RoleAdmin)
+	services.DB.Model(&inactive).Update("active", false)
+	admin := createUser(t, models.RoleAdmin)
+
+	assertErrorCode(t, updateRole(t, inactive, admin, models.RoleUser), http.StatusConflict, models.ErrCodeLastAdmin)
+	var saved models.User
+	services.DB.First(&saved, admin.ID)
+	if saved.Role != models.RoleAdmin {
+		t.Fatalf("role = %s, want %s", saved.Role, models.RoleAdmin)
+	}
+}
+
+func TestUpdateUserRoleConcurrentDemotionsKeepAnAdmin(t *testing.T) {
+	setupDB(t)
+	admins := []models.User{createUser(t, models.RoleAdmin), createUser(t, models.RoleAdmin)}
+
+	recorders := make([]*httptest.ResponseRecorder, len(admins))
+	var wg sync.WaitGroup
+	for i := range admins {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorders[i] = updateRole(t, admins[i], admins[1-i], models.RoleUser)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, recorder := range recorders {
+		if recorder.Code == http.StatusOK {
+			succeeded++
+			continue
+		}
+		assertErrorCode(t, recorder, http.StatusConflict, models.ErrCodeLastAdmin)
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d demotions succeeded, want 1", succeeded)
+	}
+	var remaining int64
+	services.DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("%d admins left, want 1", remaining)
+	}
+}
+
+func TestLoginRehashesWeakPassword(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	weak, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	services.DB.Model(&user).Update("password", string(weak))
+
+	assertStatus(t, login(t, user), http.StatusOK)
+
+	var saved models.User
+	services.DB.First(&saved, user.ID)
+	cost, err := bcrypt.Cost([]byte(saved.Password))
+	if err != nil {
+		t.Fatalf("stored hash is invalid: %v", err)
+	}
+	if cost != utils.BcryptCost {
+		t.Fatalf("stored hash cost = %d, want %d", cost, utils.BcryptCost)
+	}
+	if !utils.CheckPassword(saved.Password, testPassword) {
+		t.Fatal("rehashed password does not match")
+	}
+
+	// Хеш с актуальной стоимостью при входе не пересчитывается
+	assertStatus(t, login(t, user), http.StatusOK)
+	var again models.User
+	services.DB.First(&again, user.ID)
+	if again.Password != saved.Password {
+		t.Fatal("password rehashed although the cost is current")
+	}
+}
+
+func TestGetUserByIDLookupErrorIsNotReportedAsNotFound(t *testing.T) {
+	useUnreachableDB(t)
+	admin := models.User{ID: 1, Role: models.RoleAdmin}
+
+	recorder := perform(t, GetUserByID, testRequest{method: http.MethodGet, route: "/users/:id", target: "/users/2", user: &admin})
+	assertErrorCode(t, recorder, http.StatusInternalServerError, models.ErrCodeInternal)
+}
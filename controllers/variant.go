@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"project/models"
+	"project/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findVariantProduct проверяет ID продукта из пути и наличие продукта. При ошибке отвечает клиенту
+func findVariantProduct(c *gin.Context) (models.Product, bool) {
+	var product models.Product
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid product ID")
+		return product, false
+	}
+
+	if err := requestDB(c).First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeProductNotFound, "Product not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch product")
+		}
+		return product, false
+	}
+	return product, true
+}
+
+// findProductVariant ищет вариант из пути среди вариантов продукта. При ошибке отвечает клиенту
+func findProductVariant(c *gin.Context, productID int) (models.ProductVariant, bool) {
+	var variant models.ProductVariant
+
+	variantID, err := strconv.Atoi(c.Param("variant_id"))
+	if err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid variant ID")
+		return variant, false
+	}
+
+	if err := requestDB(c).Where("id = ? AND product_id = ?", variantID, productID).First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.HandleError(c, http.StatusNotFound, models.ErrCodeVariantNotFound, "Variant not found")
+		} else {
+			utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch variant")
+		}
+		return variant, false
+	}
+	return variant, true
+}
+
+// GetProductVariants godoc
+// @Summary Варианты продукта
+// @Description Возвращает варианты продукта (вкус, фасовка) с их остатками и ценами. Если у варианта не задана цена, действует цена продукта.
+// @Tags products
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        id path int true "ID продукта"
+// @Success 200 {array} models.ProductVariant "Варианты продукта"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Продукт не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/{id}/variants [get]
+func GetProductVariants(c *gin.Context) {
+	product, ok := findVariantProduct(c)
+	if !ok {
+		return
+	}
+
+	variants := []models.ProductVariant{}
+	if err := requestDB(c).Where("product_id = ?", product.ID).Order("id asc").Find(&variants).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch variants")
+		return
+	}
+
+	c.JSON(http.StatusOK, variants)
+}
+
+// CreateProductVariant godoc
+// @Summary Создание варианта продукта
+// @Description Добавляет продукту вариант с набором атрибутов, остатком и необязательной собственной ценой.
+// @Tags products
+// @Accept  json
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        id path int true "ID продукта"
+// @Param        variant body models.ProductVariantRequest true "Данные варианта"
+// @Success 201 {object} models.ProductVariant "Созданный вариант"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Продукт не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/{id}/variants [post]
+func CreateProductVariant(c *gin.Context) {
+	product, ok := findVariantProduct(c)
+	if !ok {
+		return
+	}
+
+	var request models.ProductVariantRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	variant := models.ProductVariant{
+		ProductID:  product.ID,
+		Attributes: request.Attributes,
+		Price:      request.Price,
+		Stock:      request.Stock,
+	}
+	if err := requestDB(c).Create(&variant).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create variant")
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+// UpdateProductVariant godoc
+// @Summary Обновление варианта продукта
+// @Description Полностью заменяет атрибуты, цену и остаток варианта. Если цена не передана, вариант продается по цене продукта.
+// @Tags products
+// @Accept  json
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        id path int true "ID продукта"
+// @Param        variant_id path int true "ID варианта"
+// @Param        variant body models.ProductVariantRequest true "Новые данные варианта"
+// @Success 200 {object} models.ProductVariant "Обновленный вариант"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Продукт или вариант не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/{id}/variants/{variant_id} [put]
+func UpdateProductVariant(c *gin.Context) {
+	product, ok := findVariantProduct(c)
+	if !ok {
+		return
+	}
+
+	variant, ok := findProductVariant(c, product.ID)
+	if !ok {
+		return
+	}
+
+	var request models.ProductVariantRequest
+	if err := utils.BindAndValidate(c, &request); err != nil {
+		utils.HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	variant.Attributes = request.Attributes
+	variant.Price = request.Price
+	variant.Stock = request.Stock
+	if err := requestDB(c).Save(&variant).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update variant")
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// DeleteProductVariant godoc
+// @Summary Удаление варианта продукта
+// @Description Мягко удаляет вариант продукта. Вариант остается доступен в истории заказов, но больше не может быть заказан.
+// @Tags products
+// @Produce  json
+// @Param        Authorization header string false "токен"
+// @Param        id path int true "ID продукта"
+// @Param        variant_id path int true "ID варианта"
+// @Success 200 {object} models.MessageResponse "Вариант удален"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 404 {object} models.ErrorResponse "Продукт или вариант не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Security BearerAuth
+// @Router /products/{id}/variants/{variant_id} [delete]
+func DeleteProductVariant(c *gin.Context) {
+	product, ok := findVariantProduct(c)
+	if !ok {
+		return
+	}
+
+	variant, ok := findProductVariant(c, product.ID)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(c).Delete(&variant).Error; err != nil {
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete variant")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageResponse{
+		Message: "variant deleted",
+	})
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"project/services"
+	"sync"
+	"testing"
+)
+
+func TestCheckoutDoesNotOversellVariant(t *testing.T) {
+	setupDB(t)
+	product := createProduct(t, 10, 5)
+	variant := createVariant(t, product, 1)
+
+	users := []models.User{createUser(t, models.RoleUser), createUser(t, models.RoleUser)}
+	orders := make([]models.Order, len(users))
+	for i, user := range users {
+		orders[i] = createOrder(t, user, models.OrderStatusPending,
+			models.OrderProduct{ProductID: product.ID, VariantID: &variant.ID, Quantity: 1, PriceAtPurchase: 10})
+		reserve(t, orders[i], product, 1)
+	}
+
+	recorders := make([]*httptest.ResponseRecorder, len(orders))
+	var wg sync.WaitGroup
+	for i := range orders {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorders[i] = orderAction(t, CheckoutOrder, "checkout", users[i], orders[i])
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, recorder := range recorders {
+		switch recorder.Code {
+		case http.StatusOK:
+			succeeded++
+		case http.StatusConflict:
+			var body models.ErrorResponse
+			json.Unmarshal(recorder.Body.Bytes(), &body)
+			if body.ErrorCode != models.ErrCodeInsufficientStock {
+				t.Fatalf("error_code = %s, want %s", body.ErrorCode, models.ErrCodeInsufficientStock)
+			}
+			if status := reloadOrderStatus(t, orders[i].ID); status != models.OrderStatusPending {
+				t.Fatalf("rejected order status = %s, want %s", status, models.OrderStatusPending)
+			}
+		default:
+			t.Fatalf("status = %d; body: %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d checkouts succeeded, want 1", succeeded)
+	}
+
+	var left models.ProductVariant
+	services.DB.First(&left, variant.ID)
+	if left.Stock != 0 {
+		t.Fatalf("variant stock = %d, want 0", left.Stock)
+	}
+	if got := reloadProduct(t, product.ID); got.Stock != 4 || got.Reserved != 1 {
+		t.Fatalf("product stock/reserved = %d/%d, want 4/1", got.Stock, got.Reserved)
+	}
+}
+
+func TestCheckoutRejectsDeletedVariant(t *testing.T) {
+	setupDB(t)
+	user := createUser(t, models.RoleUser)
+	product := createProduct(t, 10, 5)
+	variant := createVariant(t, product, 3)
+	order := createOrder(t, user, models.OrderStatusPending,
+		models.OrderProduct{ProductID: product.ID, VariantID: &variant.ID, Quantity: 1, PriceAtPurchase: 10})
+	reserve(t, order, product, 1)
+	services.DB.Delete(&variant)
+
+	recorder := orderAction(t, CheckoutOrder, "checkout", user, order)
+	assertErrorCode(t, recorder, http.StatusConflict, models.ErrCodeVariantNotFound)
+	if got := reloadProduct(t, product.ID); got.Stock != 5 {
+		t.Fatalf("product stock = %d, want 5", got.Stock)
+	}
+}
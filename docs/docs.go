@@ -24,24 +24,21 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/admin/orders": {
+        "/admin/audit-log": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список заказов, включая информацию о продуктах в заказах",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает записи журнала аудита, начиная с новых, с пагинацией и фильтрами по администратору и действию",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "audit"
                 ],
-                "summary": "Получение списка всех заказов",
+                "summary": "Журнал действий администраторов",
                 "parameters": [
                     {
                         "type": "string",
@@ -64,111 +61,33 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "default": "id",
-                        "description": "Поле для сортировки",
-                        "name": "sort",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "asc",
-                        "description": "Направление сортировки",
-                        "name": "order",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "ID пользователя",
-                        "name": "user_id",
+                        "type": "integer",
+                        "description": "ID администратора",
+                        "name": "actor_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "ID заказа",
-                        "name": "order_id",
+                        "description": "Действие, например user.role_update",
+                        "name": "action",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список заказов с продуктами",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.OrderResponse"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Ошибка сервера",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/admin/orders/{id}": {
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Удаляет указанный заказ вместе с привязанными продуктами.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "orders"
-                ],
-                "summary": "Удаление заказа",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Токен пользователя",
-                        "name": "Authorization",
-                        "in": "header"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Успешное удаление заказа",
+                        "description": "Записи журнала",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.AuditLogResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Неавторизованный доступ",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Заказ не найден",
+                        "description": "Некорректные параметры запроса",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка на сервере",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -176,24 +95,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/categories": {
+        "/admin/coupons": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список категорий с предзагрузкой связанных продуктов, ограничивая время выполнения запроса до 2 секунд.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает все купоны со сроками действия и счетчиками использований",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "coupons"
                 ],
-                "summary": "Получение списка категорий с тайм-аутом",
+                "summary": "Получение списка купонов",
                 "parameters": [
                     {
                         "type": "string",
@@ -204,20 +120,14 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список категорий с предзагруженными продуктами",
+                        "description": "Список купонов",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Category"
+                                "$ref": "#/definitions/models.Coupon"
                             }
                         }
                     },
-                    "408": {
-                        "description": "Тайм-аут запроса",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -232,7 +142,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создает новую категорию в базе данных на основе переданных данных.",
+                "description": "Создает купон с процентной (percent) или фиксированной (fixed) скидкой",
                 "consumes": [
                     "application/json"
                 ],
@@ -240,9 +150,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "coupons"
                 ],
-                "summary": "Создание новой категории",
+                "summary": "Создание купона",
                 "parameters": [
                     {
                         "type": "string",
@@ -251,20 +161,26 @@ const docTemplate = `{
                         "in": "header"
                     },
                     {
-                        "description": "Данные категории",
-                        "name": "category",
+                        "description": "Данные купона",
+                        "name": "coupon",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Созданная категория",
-                        "schema": {
-                            "$ref": "#/definitions/models.Category"
+                        "description": "Созданный купон",
+                        "schema": {
+                            "$ref": "#/definitions/models.Coupon"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданного купона"
+                            }
                         }
                     },
                     "400": {
@@ -273,6 +189,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Купон с таким кодом уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -282,24 +204,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/categories/{id}": {
+        "/admin/coupons/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает информацию о категории на основе переданного идентификатора.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает купон по идентификатору",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "coupons"
                 ],
-                "summary": "Получение категории по ID",
+                "summary": "Получение купона по ID",
                 "parameters": [
                     {
                         "type": "string",
@@ -309,7 +228,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Идентификатор категории",
+                        "description": "Идентификатор купона",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -317,19 +236,13 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Информация о категории",
+                        "description": "Купон",
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     },
                     "404": {
-                        "description": "Категория не найдена",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Купон не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -342,7 +255,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет категорию с переданными данными на основе ID",
+                "description": "Обновляет параметры купона. Счетчик использований не изменяется.",
                 "consumes": [
                     "application/json"
                 ],
@@ -350,9 +263,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "coupons"
                 ],
-                "summary": "Обновление категории",
+                "summary": "Обновление купона",
                 "parameters": [
                     {
                         "type": "string",
@@ -362,36 +275,42 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Идентификатор категории",
+                        "description": "Идентификатор купона",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Обновленные данные категории",
-                        "name": "category",
+                        "description": "Обновленные данные купона",
+                        "name": "coupon",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Категория успешно обновлена",
+                        "description": "Обновленный купон",
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Некорректные данные",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Категория не найдена",
+                        "description": "Купон не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Купон с таким кодом уже существует",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -410,17 +329,14 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет категорию по переданному ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Удаляет купон. Скидки в уже оформленных заказах сохраняются.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "coupons"
                 ],
-                "summary": "Удаление категории",
+                "summary": "Удаление купона",
                 "parameters": [
                     {
                         "type": "string",
@@ -430,7 +346,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Идентификатор категории",
+                        "description": "Идентификатор купона",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -438,13 +354,13 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Категория успешно удалена",
+                        "description": "Купон удален",
                         "schema": {
                             "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
                     "404": {
-                        "description": "Категория не найдена",
+                        "description": "Купон не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -458,9 +374,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Эндпоинт для авторизации пользователя. При успешной авторизации возвращает JWT-токен.",
+        "/admin/orders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список заказов, включая информацию о продуктах в заказах",
                 "consumes": [
                     "application/json"
                 ],
@@ -468,41 +389,75 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "orders"
                 ],
-                "summary": "Авторизация пользователя",
+                "summary": "Получение списка всех заказов",
                 "parameters": [
                     {
-                        "description": "Учетные данные пользователя",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Credentials"
-                        }
+                        "type": "string",
+                        "description": "Токен доступа пользователя (JWT)",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Поле для сортировки",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID заказа",
+                        "name": "order_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Возвращает jwt-токен",
+                        "description": "Список заказов с продуктами",
                         "schema": {
-                            "$ref": "#/definitions/models.TokenResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderResponse"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Некорректный пароль",
+                        "description": "Некорректные параметры пагинации",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Невозможно создать токен",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -510,14 +465,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders": {
-            "get": {
+        "/admin/orders/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список заказов, связанных с пользователем, включая информацию о продуктах в заказах",
+                "description": "Удаляет указанный заказ вместе с привязанными продуктами.",
                 "consumes": [
                     "application/json"
                 ],
@@ -527,23 +482,27 @@ const docTemplate = `{
                 "tags": [
                     "orders"
                 ],
-                "summary": "Получение списка заказов пользователя",
+                "summary": "Удаление заказа",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен доступа пользователя (JWT)",
+                        "description": "Токен пользователя",
                         "name": "Authorization",
                         "in": "header"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Список заказов с продуктами",
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное удаление заказа",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Order"
-                            }
+                            "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
                     "400": {
@@ -558,63 +517,62 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Ошибка на сервере",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/products/low-stock": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создает новый заказ и связывает с ним продукты. Если продукты не указаны, заказ будет создан без них.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает продукты с остатком на складе не больше порога, по возрастанию остатка. Порог по умолчанию задается LOW_STOCK_THRESHOLD.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "products"
                 ],
-                "summary": "Создание нового заказа",
+                "summary": "Заканчивающиеся продукты",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "JWT токен пользователя",
+                        "description": "токен",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
-                        "description": "Данные для создания заказа",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateOrderRequest"
-                        }
+                        "type": "integer",
+                        "description": "Порог остатка",
+                        "name": "threshold",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Заказ успешно создан",
+                        "description": "Продукты с низким остатком",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса или продукт не найден",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Неавторизованный доступ",
+                        "description": "Некорректный порог",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -628,125 +586,80 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{id}": {
+        "/admin/products/orphaned": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает данные заказа, включая связанные продукты, если заказ принадлежит авторизованному пользователю",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает продукты, которые ссылаются на несуществующую категорию, например после удаления категории или импорта данных.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "products"
                 ],
-                "summary": "Получение информации о заказе по идентификатору",
+                "summary": "Продукты без категории",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен доступа пользователя (JWT)",
+                        "description": "токен",
                         "name": "Authorization",
                         "in": "header"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Идентификатор заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Информация о заказе с продуктами",
-                        "schema": {
-                            "$ref": "#/definitions/models.Order"
-                        }
-                    },
-                    "400": {
-                        "description": "Некорректный запрос",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Неавторизованный доступ",
+                        "description": "Продукты без категории",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Заказ не найден",
+                    "500": {
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/products/orphaned/repair": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет указанный заказ текущего пользователя вместе с привязанными продуктами.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Переносит продукты, которые ссылаются на несуществующую категорию, в категорию UNCATEGORIZED_CATEGORY_NAME.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "products"
                 ],
-                "summary": "Удаление заказа",
+                "summary": "Перенос продуктов без категории",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен пользователя",
+                        "description": "токен",
                         "name": "Authorization",
                         "in": "header"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное удаление заказа",
-                        "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Некорректный запрос",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Неавторизованный доступ",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Заказ не найден",
+                        "description": "Категория и число перенесенных продуктов",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.RepairOrphanedProductsResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка на сервере",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -754,14 +667,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{id}/products": {
+        "/admin/products/{id}/merge": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Добавляет продукт в заказ текущего пользователя. Если продукт уже существует в заказе, его количество увеличивается.",
+                "description": "Переносит отзывы, позиции заказов, резервы, варианты и остаток продукта id на продукт target_id, после чего удаляет продукт id (мягкое удаление). Рейтинг target_id пересчитывается.\nЕсли в заказе уже есть позиция target_id с тем же вариантом и той же ценой покупки, количество позиции дубликата добавляется к ней. Если вариант или цена различаются, слияние отклоняется с кодом 409 и списком таких заказов. Отзыв автора, уже оценившего target_id, удаляется, чтобы сохранить правило одного отзыва на продукт.",
                 "consumes": [
                     "application/json"
                 ],
@@ -769,61 +682,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "products"
                 ],
-                "summary": "Добавление продукта в заказ",
+                "summary": "Слияние продукта-дубликата",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен пользователя",
+                        "description": "токен",
                         "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "in": "header"
                     },
                     {
                         "type": "integer",
-                        "description": "ID заказа",
+                        "description": "ID продукта-дубликата",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Продукт для добавления в заказ",
-                        "name": "product",
+                        "description": "ID продукта, который остается",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ProductInOrder"
+                            "$ref": "#/definitions/models.MergeProductRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное добавление продукта",
+                        "description": "Итог слияния",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.MergeProductResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Некорректный запрос или неизвестный target_id",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Неавторизованный доступ",
+                    "404": {
+                        "description": "Продукт не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Заказ не найден",
+                    "409": {
+                        "description": "В заказах есть позиции обоих продуктов с разными вариантами или ценами",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка на сервере",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -831,55 +743,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{id}/products/{product_id}": {
-            "delete": {
+        "/admin/reports/sales": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет указанный продукт из заказа текущего пользователя.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает выручку, количество оформленных заказов и самые продаваемые продукты за период.\nУчитываются только оформленные заказы (статус completed); суммы пересчитываются в базовую валюту по текущим ценам продуктов.\nВыручка учитывает скидки купонов: скидка заказа распределяется между его позициями пропорционально их сумме.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "reports"
                 ],
-                "summary": "Удаление продукта из заказа",
+                "summary": "Отчет о продажах",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен пользователя",
+                        "description": "Токен доступа администратора (JWT)",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
-                        "type": "integer",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "ID продукта для удаления",
-                        "name": "product_id",
-                        "in": "path",
-                        "required": true
+                        "default": 10,
+                        "description": "Количество продуктов в рейтинге",
+                        "name": "top",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное удаление продукта",
+                        "description": "Сводка продаж",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.SalesReportResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Некорректные параметры",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -890,95 +804,97 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Продукт или заказ не найден",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка на сервере",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/admin/reviews": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет количество указанного продукта в заказе текущего пользователя.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает отзывы по всем продуктам для модерации, начиная с новых, с пагинацией и фильтрами по продукту, автору и оценке",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "products"
                 ],
-                "summary": "Обновление количества продукта в заказе",
+                "summary": "Получение списка всех отзывов",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен пользователя",
+                        "description": "Токен доступа пользователя (JWT)",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
                         "type": "integer",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
                         "description": "ID продукта",
                         "name": "product_id",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "description": "Новое количество продукта",
-                        "name": "quantity",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UpdateProductQuantityRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID автора отзыва",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Минимальная оценка",
+                        "name": "min_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальная оценка",
+                        "name": "max_rating",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное обновление количества продукта",
+                        "description": "Список отзывов",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.AdminReviewsResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Неавторизованный доступ",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Продукт или заказ не найден",
+                        "description": "Некорректные параметры запроса",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка на сервере",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -986,77 +902,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/products": {
-            "get": {
+        "/admin/reviews/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Получает список продуктов с применением фильтров, сортировки и пагинации с тайм-аутом в 2 секунды",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Удаляет любой отзыв и пересчитывает рейтинг продукта по оставшимся отзывам",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "products"
                 ],
-                "summary": "Получение списка продуктов с тайм-аутом",
+                "summary": "Удаление отзыва администратором",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "токен",
+                        "description": "Токен доступа пользователя (JWT)",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
                         "type": "integer",
-                        "default": 1,
-                        "description": "Номер страницы",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Количество элементов на странице",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "id",
-                        "description": "Поле для сортировки",
-                        "name": "sort",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "asc",
-                        "description": "Направление сортировки",
-                        "name": "order",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Название продукта",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "ID категории",
-                        "name": "category_id",
-                        "in": "query"
+                        "description": "ID отзыва",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный запрос",
+                        "description": "Отзыв удален",
                         "schema": {
-                            "$ref": "#/definitions/models.ProductResponse"
+                            "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
                     "400": {
@@ -1066,13 +946,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Продукты не найдены",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "408": {
-                        "description": "Тайм-аут запроса",
+                        "description": "Отзыв не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1084,14 +958,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/users": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создает новый продукт с указанными параметрами",
+                "description": "Создает учетную запись с указанной ролью (\"user\" по умолчанию или \"admin\"). Пароль проверяется по действующей политике и сохраняется в виде хеша.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1099,35 +975,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "users"
                 ],
-                "summary": "Создание нового продукта",
+                "summary": "Создание пользователя администратором",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "токен",
+                        "description": "Токен авторизации",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
-                        "description": "Данные продукта",
-                        "name": "product",
+                        "description": "Данные пользователя",
+                        "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.CreateUserRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Успешное создание",
-                        "schema": {
-                            "$ref": "#/definitions/models.Product"
+                        "description": "Созданный пользователь без пароля",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserProfile"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданного пользователя"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя или email уже заняты",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки в полях, перечисленные в fields",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1141,14 +1035,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/count-by-manufacturer": {
+        "/admin/users/{id}/orders": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Выполняет агрегацию, подсчитывая количество продуктов, сгруппированных по производителям.",
+                "description": "Возвращает заказы указанного пользователя с продуктами, с пагинацией",
                 "consumes": [
                     "application/json"
                 ],
@@ -1156,25 +1050,55 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "orders"
                 ],
-                "summary": "Подсчет количества продуктов по производителям",
+                "summary": "Получение заказов пользователя администратором",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "токен",
+                        "description": "Токен доступа пользователя (JWT)",
                         "name": "Authorization",
                         "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Результаты агрегации с производителями и их количеством",
+                        "description": "Список заказов пользователя с продуктами",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.CountProdutsResponse"
-                            }
+                            "$ref": "#/definitions/models.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1186,14 +1110,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/manufacturer": {
-            "put": {
+        "/categories": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет поле \"manufacturer\" у всех продуктов в базе данных на указанное значение.",
+                "description": "Возвращает список категорий с пагинацией и количеством продуктов в каждой. При with_products=false продукты не загружаются. Время выполнения запроса ограничено REQUEST_TIMEOUT.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1201,9 +1125,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "categories"
                 ],
-                "summary": "Массовое обновление производителя продуктов",
+                "summary": "Получение списка категорий с тайм-аутом",
                 "parameters": [
                     {
                         "type": "string",
@@ -1212,43 +1136,61 @@ const docTemplate = `{
                         "in": "header"
                     },
                     {
-                        "type": "string",
-                        "description": "Новое значение для производителя",
-                        "name": "manufacturer",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Загружать продукты категорий",
+                        "name": "with_products",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное обновление",
+                        "description": "Список категорий",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.CategoryResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Тайм-аут запроса",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка сервера или транзакции",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/products/price-range": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список продуктов, цены которых находятся в заданном диапазоне",
+                "description": "Создает новую категорию в базе данных на основе переданных данных. Название обязательно и должно быть уникальным без учета регистра.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1256,9 +1198,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "categories"
                 ],
-                "summary": "Получение продуктов по диапазону цен",
+                "summary": "Создание новой категории",
                 "parameters": [
                     {
                         "type": "string",
@@ -1267,38 +1209,36 @@ const docTemplate = `{
                         "in": "header"
                     },
                     {
-                        "type": "number",
-                        "description": "Минимальная цена",
-                        "name": "minPrice",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "Максимальная цена",
-                        "name": "maxPrice",
-                        "in": "query",
-                        "required": true
+                        "description": "Данные категории",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Список продуктов в заданном диапазоне цен",
+                    "201": {
+                        "description": "Созданная категория",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.Category"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданной категории"
                             }
                         }
                     },
                     "400": {
-                        "description": "Некорректные значения цен",
+                        "description": "Некорректные данные",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Продукты не найдены в указанном диапазоне",
+                    "409": {
+                        "description": "Категория с таким названием уже существует",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1312,21 +1252,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/{id}": {
+        "/categories/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Получает информацию о продукте по уникальному идентификатору",
+                "description": "Возвращает информацию о категории на основе переданного идентификатора. Продукты категории включаются только при expand=products.\nПоддерживает условный запрос через If-None-Match.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "categories"
                 ],
-                "summary": "Получение продукта по ID",
+                "summary": "Получение категории по ID",
                 "parameters": [
                     {
                         "type": "string",
@@ -1335,29 +1278,56 @@ const docTemplate = `{
                         "in": "header"
                     },
                     {
-                        "type": "string",
-                        "description": "ID продукта",
+                        "type": "integer",
+                        "description": "Идентификатор категории",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Связи для загрузки через запятую: products",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag ранее полученного ответа",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный запрос",
+                        "description": "Информация о категории",
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.Category"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш представления категории"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Продукт не найден",
+                    "304": {
+                        "description": "Категория не изменилась"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера",
-                        "schema": {
+                    "404": {
+                        "description": "Категория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
@@ -1369,7 +1339,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет данные продукта по указанному ID",
+                "description": "Заменяет название и описание категории по ID; пустое описание очищает его. Название обязательно и должно быть уникальным без учета регистра.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1377,9 +1347,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "categories"
                 ],
-                "summary": "Обновление продукта",
+                "summary": "Обновление категории",
                 "parameters": [
                     {
                         "type": "string",
@@ -1389,26 +1359,26 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "ID продукта",
+                        "description": "Идентификатор категории",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Обновленные данные продукта",
-                        "name": "product",
+                        "description": "Обновленные данные категории",
+                        "name": "category",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.Category"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное обновление",
+                        "description": "Категория успешно обновлена",
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.Category"
                         }
                     },
                     "400": {
@@ -1418,7 +1388,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Продукт не найден",
+                        "description": "Категория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Категория с таким названием уже существует",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1437,14 +1413,17 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет продукт по указанному ID",
+                "description": "Удаляет категорию по переданному ID",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "categories"
                 ],
-                "summary": "Удаление продукта",
+                "summary": "Удаление категории",
                 "parameters": [
                     {
                         "type": "string",
@@ -1453,8 +1432,8 @@ const docTemplate = `{
                         "in": "header"
                     },
                     {
-                        "type": "string",
-                        "description": "ID продукта",
+                        "type": "integer",
+                        "description": "Идентификатор категории",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1462,13 +1441,25 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное удаление продукта",
+                        "description": "Категория успешно удалена",
                         "schema": {
                             "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
+                    "400": {
+                        "description": "Некорректный ID категории",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Продукт не найден",
+                        "description": "Категория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Категорию для продуктов без категории удалить нельзя",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1480,115 +1471,179 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/products/{id}/reviews": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all reviews for a specific product",
+                "description": "Обновляет только переданные поля категории; пустая строка в description очищает описание.\nПри переданном move_products_to все продукты категории переносятся в указанную категорию в той же транзакции.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "products"
+                    "categories"
                 ],
-                "summary": "Получение отзывов продукта",
+                "summary": "Частичное обновление категории",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "JWT токен пользователя",
+                        "description": "токен",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
                         "type": "integer",
-                        "description": "Product ID",
+                        "description": "Идентификатор категории",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Изменяемые поля категории",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PatchCategoryRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Категория и число перенесенных продуктов",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Review"
-                            }
+                            "$ref": "#/definitions/models.PatchCategoryResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Некорректный запрос или неизвестная категория для переноса",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Категория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Категория с таким названием уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/categories/{id}/products": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создает новый отзыв.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает продукты категории в кратком виде с теми же фильтрами, сортировкой и пагинацией, что и список продуктов",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "categories"
                 ],
-                "summary": "Создание нового отзыва",
+                "summary": "Получение продуктов категории",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "JWT токен пользователя",
+                        "description": "токен",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
-                        "type": "string",
-                        "description": "ID продукта",
+                        "type": "integer",
+                        "description": "Идентификатор категории",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные для создания отзыва",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateReviewRequest"
-                        }
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Поле для сортировки: id, name, price, manufacturer, category_id, stock, rating, review_count",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Строка поиска по названию и описанию продукта",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Искать без учета диакритики (é = e)",
+                        "name": "normalize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта, в которой вернуть цены (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Отзыв успешно создан",
+                        "description": "Продукты категории",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.ProductResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса",
+                        "description": "Некорректный запрос",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Неавторизованный доступ",
+                    "404": {
+                        "description": "Категория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Тайм-аут запроса",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1602,9 +1657,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/refresh": {
+        "/healthz": {
+            "get": {
+                "description": "Всегда возвращает 200, если процесс запущен и обрабатывает запросы.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка работоспособности сервиса",
+                "responses": {
+                    "200": {
+                        "description": "Сервис работает",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
             "post": {
-                "description": "Эндпоинт для обновления JWT токена. Генерирует новый токен, если исходный почти истек.",
+                "description": "Эндпоинт для авторизации пользователя. При успешной авторизации возвращает короткоживущий JWT-токен доступа и долгоживущий токен обновления.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1614,31 +1689,45 @@ const docTemplate = `{
                 "tags": [
                     "auth"
                 ],
-                "summary": "Обновление токена",
+                "summary": "Авторизация пользователя",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "токен",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "description": "Учетные данные пользователя",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Credentials"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "новый JWT токен",
+                        "description": "Возвращает jwt-токен и токен обновления",
                         "schema": {
                             "$ref": "#/definitions/models.TokenResponse"
                         }
                     },
                     "400": {
-                        "description": "Токен еще не истек",
+                        "description": "Некорректный запрос",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Пользователь не авторизирован",
+                        "description": "Некорректный пароль",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Учетная запись деактивирована",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток входа",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1652,9 +1741,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
+        "/logout": {
             "post": {
-                "description": "Эндпоинт для регистрации нового пользователя. Возвращает сообщение об успешной регистрации.",
+                "description": "Отзывает токен обновления. Уже выданный токен доступа остается действительным до истечения срока.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1664,21 +1753,21 @@ const docTemplate = `{
                 "tags": [
                     "auth"
                 ],
-                "summary": "Регистрация пользователя",
+                "summary": "Выход из системы",
                 "parameters": [
                     {
-                        "description": "Учетные данные пользователя (username, password, optional: role)",
-                        "name": "credentials",
+                        "description": "Токен обновления",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Credentials"
+                            "$ref": "#/definitions/models.RefreshTokenRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Пользователь успешно зарегистрирован",
+                    "200": {
+                        "description": "Токен отозван",
                         "schema": {
                             "$ref": "#/definitions/models.MessageResponse"
                         }
@@ -1689,14 +1778,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Пользователь уже существует",
+                    "401": {
+                        "description": "Токен обновления недействителен",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Невозможно зарегистрировать пользователя",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1704,14 +1793,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/orders": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает данные всех пользователей.",
+                "description": "Возвращает заказы пользователя с продуктами, с пагинацией и необязательной фильтрацией по дате создания (RFC3339, границы включительно)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1719,44 +1808,77 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "orders"
                 ],
-                "summary": "Получение списка всех пользователей",
+                "summary": "Получение списка заказов пользователя",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен авторизации",
+                        "description": "Токен доступа пользователя (JWT)",
                         "name": "Authorization",
                         "in": "header"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Список пользователей",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
-                            }
-                        }
                     },
-                    "500": {
-                        "description": "Ошибка сервера",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список заказов с продуктами",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/users/me": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Получает информацию о текущем пользователе, включая его имя и роль. Пароль в ответе не передается.",
+                "description": "Создает новый заказ и связывает с ним продукты. Если продукты не указаны, заказ будет создан без них.\nПри повторе запроса с тем же заголовком Idempotency-Key возвращается исходный заказ вместо создания нового.\nПозиции заказа резервируются на складе до оформления заказа или истечения срока резерва.\nЕсли передан expected_total и он расходится с рассчитанной суммой заказа больше чем на 0.01, заказ не создается и возвращается 409 с обеими суммами.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1764,22 +1886,55 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "orders"
                 ],
-                "summary": "Получение информации о пользователе",
+                "summary": "Создание нового заказа",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен пользователя",
+                        "description": "JWT токен пользователя",
                         "name": "Authorization",
                         "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для создания заказа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateOrderRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный запрос. Информация о пользователе",
+                        "description": "Заказ, ранее созданный с этим ключом идемпотентности",
                         "schema": {
-                            "$ref": "#/definitions/models.UserInfoResponse"
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "201": {
+                        "description": "Созданный заказ с позициями",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданного заказа"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса или продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
@@ -1788,21 +1943,29 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "409": {
+                        "description": "Недостаточно товара на складе или сумма заказа не совпала с expected_total",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/orders/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Позволяет пользователю удалить свою учетную запись. Администраторы не могут удалять себя.",
+                "description": "Возвращает данные заказа, если заказ принадлежит авторизованному пользователю.\nПозиции заказа с продуктами включаются только при expand=products; суммы заказа считаются в обоих случаях.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1810,38 +1973,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "orders"
                 ],
-                "summary": "Удаление своей учетной записи",
+                "summary": "Получение информации о заказе по идентификатору",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен авторизации",
+                        "description": "Токен доступа пользователя (JWT)",
                         "name": "Authorization",
                         "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Связи для загрузки через запятую: products",
+                        "name": "expand",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Учетная запись успешно удалена",
+                        "description": "Информация о заказе",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.Order"
                         }
                     },
-                    "401": {
-                        "description": "Пользователь не авторизован",
+                    "400": {
+                        "description": "Некорректный запрос",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Администратор не может удалить себя",
+                    "401": {
+                        "description": "Неавторизованный доступ",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Заказ не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1853,16 +2029,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users/me/password": {
-            "patch": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Позволяет авторизованному пользователю изменить свой пароль, требуется указать старый и новый пароли",
+                "description": "Удаляет указанный заказ текущего пользователя вместе с привязанными продуктами.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1870,53 +2044,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "orders"
                 ],
-                "summary": "Обновление пароля пользователя",
+                "summary": "Удаление заказа",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен авторизации",
+                        "description": "Токен пользователя",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
-                        "description": "Данные для обновления пароля",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UpdatePasswordRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Пароль успешно обновлен",
+                        "description": "Успешное удаление заказа",
                         "schema": {
                             "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса",
+                        "description": "Некорректный запрос",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Пользователь не авторизован или старый пароль указан неверно",
+                        "description": "Неавторизованный доступ",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Заказ не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Ошибка на сервере",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1924,14 +2096,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/me/username": {
-            "patch": {
+        "/orders/{id}/apply-coupon": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Позволяет авторизованному пользователю обновить свое имя",
+                "description": "Проверяет код купона, срок его действия и лимит использований и сохраняет скидку в заказе текущего пользователя.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1939,59 +2111,66 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "orders"
                 ],
-                "summary": "Обновление имени пользователя",
+                "summary": "Применение купона к заказу",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен авторизации",
+                        "description": "Токен пользователя",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
-                        "description": "Данные для обновления имени пользователя",
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Код купона",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateUsernameRequest"
+                            "$ref": "#/definitions/models.ApplyCouponRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Имя пользователя успешно обновлено",
+                        "description": "Заказ с примененной скидкой",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.Order"
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса",
+                        "description": "Купон недействителен, истек или исчерпан",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Пользователь не авторизован",
+                        "description": "Неавторизованный доступ",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Заказ не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "409": {
-                        "description": "Имя пользователя уже занято",
+                        "description": "Заказ уже оформлен",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Ошибка на сервере",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -1999,34 +2178,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
-            "get": {
+        "/orders/{id}/cancel": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает данные конкретного пользователя по его ID.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Переводит заказ текущего пользователя в статус cancelled. Заказ и позиции сохраняются в истории.\nУ неоформленного заказа снимается резерв позиций, у оформленного списанные товары возвращаются на склад, в том числе остатки вариантов.\nУже отмененный заказ отменить нельзя.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "orders"
                 ],
-                "summary": "Получение данных пользователя по идентификатору",
+                "summary": "Отмена заказа",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен авторизации",
+                        "description": "Токен пользователя",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
+                        "description": "Идентификатор заказа",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -2034,9 +2210,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Данные пользователя",
+                        "description": "Отмененный заказ",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Order"
                         }
                     },
                     "400": {
@@ -2045,8 +2221,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ уже отменен",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -2058,34 +2246,33 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/orders/{id}/checkout": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Позволяет администратору удалить только пользователя с ролью \"user\"",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Оформляет заказ текущего пользователя: зарезервированные товары списываются со склада, а заказ переходит в статус completed.\nЕсли срок резерва истек, товары резервируются повторно при наличии на складе.\nДля позиций с вариантом продукта остаток варианта уменьшается на количество в позиции. Остаток варианта проверяется повторно под блокировкой; если его не хватает или вариант удален, возвращается 409.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "orders"
                 ],
-                "summary": "Удаление пользователя с ролью \"user\"",
+                "summary": "Оформление заказа",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен авторизации",
+                        "description": "Токен пользователя",
                         "name": "Authorization",
                         "in": "header"
                     },
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
+                        "description": "ID заказа",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -2093,25 +2280,37 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Пользователь успешно удален",
+                        "description": "Оформленный заказ",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.Order"
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса или удаление невозможно",
+                        "description": "Некорректный запрос или пустой заказ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Недостаточно товара на складе или заказ уже оформлен",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Ошибка на сервере",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -2119,14 +2318,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}/role": {
-            "patch": {
-                "security": [
+        "/orders/{id}/products": {
+            "post": {
+                "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Позволяет администратору изменить роль пользователя только с \"user\" на \"admin\"",
+                "description": "Добавляет продукт в заказ текущего пользователя. Если продукт уже существует в заказе, его количество увеличивается.\nМожно указать variant_id варианта продукта; продукт входит в заказ не более чем с одним вариантом.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2134,261 +2333,4472 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "orders"
                 ],
-                "summary": "Обновление роли пользователя на администратора",
+                "summary": "Добавление продукта в заказ",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Токен авторизации",
+                        "description": "Токен пользователя",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
+                        "description": "ID заказа",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные для обновления роли",
-                        "name": "data",
+                        "description": "Продукт для добавления в заказ",
+                        "name": "product",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateUserRoleRequest"
+                            "$ref": "#/definitions/models.ProductInOrder"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Роль пользователя обновлена на администратора",
+                        "description": "Успешное добавление продукта",
                         "schema": {
                             "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса или обновление роли невозможно",
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Недостаточно товара на складе или заказ уже оформлен",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет все продукты из заказа текущего пользователя, сохраняя сам заказ.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Очистка заказа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Количество удаленных позиций",
+                        "schema": {
+                            "$ref": "#/definitions/models.RemovedItemsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ уже оформлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Устанавливает новое количество для нескольких позиций заказа текущего пользователя в одной транзакции.\nЕсли какого-либо продукта нет в заказе или количество меньше единицы, заказ не меняется.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Обновление количества нескольких продуктов в заказе",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Продукты и их новое количество",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateOrderProductsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленные позиции заказа",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderProduct"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден или продукта нет в заказе",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Недостаточно товара на складе или заказ уже оформлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Category": {
+        },
+        "/orders/{id}/products/batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет в заказ текущего пользователя несколько продуктов за один запрос в одной транзакции.\nДля продуктов, уже присутствующих в заказе, количество увеличивается. При ошибке в любой позиции заказ не меняется.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Добавление нескольких продуктов в заказ",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Продукты для добавления в заказ",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddOrderProductsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Итоговые позиции заказа по добавленным продуктам",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderProduct"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос или продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Недостаточно товара на складе или заказ уже оформлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/products/{product_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет указанный продукт из заказа текущего пользователя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Удаление продукта из заказа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта для удаления",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное удаление продукта",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт или заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ уже оформлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет количество указанного продукта в заказе текущего пользователя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Обновление количества продукта в заказе",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое количество продукта",
+                        "name": "quantity",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateProductQuantityRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное обновление количества продукта",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт или заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Недостаточно товара на складе или заказ уже оформлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/reorder": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает новый заказ текущего пользователя с теми же позициями и количествами, что и в указанном заказе.\nПродукты, которые больше не существуют, пропускаются и перечисляются в ответе.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Повтор заказа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID исходного заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Новый заказ и пропущенные продукты",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReorderResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданного заказа"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Недостаточно товара на складе",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/summary": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает количество позиций, общее количество товаров, сумму заказа и расшифровку по каждой позиции",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Сводка по заказу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен доступа пользователя (JWT)",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сводка по заказу",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/password-policy": {
+            "get": {
+                "description": "Возвращает действующие правила сложности пароля, чтобы клиент мог проверить пароль до отправки.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Требования к паролю",
+                "responses": {
+                    "200": {
+                        "description": "Правила сложности пароля",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordPolicyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Получает список продуктов с применением фильтров, сортировки и пагинации. Продукты возвращаются в кратком виде без описания; полные данные доступны по GET /products/{id}. Время выполнения запроса ограничено REQUEST_TIMEOUT.\nРазмер страницы по умолчанию и его максимум задаются настройками DEFAULT_PAGE_LIMIT и MAX_PAGE_LIMIT (или PAGE_LIMIT_OVERRIDES для маршрута) и возвращаются в метаданных; при равных значениях поля сортировки продукты упорядочиваются по id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Получение списка продуктов с тайм-аутом",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Поле для сортировки: id, name, price, manufacturer, category_id, stock, rating, review_count",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Строка поиска по названию и описанию продукта",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Искать без учета диакритики (é = e)",
+                        "name": "normalize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID категории",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта, в которой вернуть цены (ISO 4217); сортировка по цене выполняется по исходным ценам",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукты не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Тайм-аут запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает новый продукт с указанными параметрами",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Создание нового продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные продукта",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Успешное создание",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданного продукта"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки в полях продукта, перечисленные в fields",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Мягко удаляет продукты с переданными ID в одной транзакции. ID, которым не соответствует продукт, возвращаются в not_found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Массовое удаление продуктов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "ID удаляемых продуктов",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteProductsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Количество удаленных продуктов и ненайденные ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteProductsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает несколько продуктов в одной транзакции. Если хотя бы один продукт некорректен, не создается ни один.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Массовое создание продуктов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Список продуктов",
+                        "name": "products",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProductRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданные продукты",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос или продукт",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/category": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переносит перечисленные продукты в категорию category_id в одной транзакции и возвращает число обновленных продуктов. Несуществующие ID продуктов пропускаются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Массовый перенос продуктов в категорию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "ID продуктов и новая категория",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateProductsCategoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Число обновленных продуктов",
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateProductsCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос или неизвестная категория",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера или транзакции",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/count-by-manufacturer": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выполняет агрегацию, подсчитывая количество продуктов, сгруппированных по производителям.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Подсчет количества продуктов по производителям",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результаты агрегации с производителями и их количеством",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CountProdutsResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выгружает каталог продуктов в формате CSV с учетом тех же фильтров, что и список продуктов. Строки передаются потоком; время выгрузки ограничено EXPORT_TIMEOUT.",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Экспорт продуктов в CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Строка поиска по названию и описанию продукта",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Искать без учета диакритики (é = e)",
+                        "name": "normalize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID категории",
+                        "name": "category_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV-файл с продуктами",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "X-Export-Status": {
+                                "type": "string",
+                                "description": "Трейлер: complete, если выгрузка завершена, incomplete — если прервана"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/manufacturer": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет поле \"manufacturer\" у всех продуктов в базе данных на указанное значение.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Массовое обновление производителя продуктов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Новое значение для производителя",
+                        "name": "manufacturer",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное обновление",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера или транзакции",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/manufacturers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает отсортированный по имени список производителей с количеством продуктов у каждого. Можно ограничить выборку категорией.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Список производителей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID категории",
+                        "name": "category_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Производители и количество их продуктов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CountProdutsResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID категории",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/price-range": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает постраничный список продуктов, цены которых находятся в заданном диапазоне. Если таких продуктов нет, возвращается пустой список",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Получение продуктов по диапазону цен",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная цена",
+                        "name": "minPrice",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Максимальная цена",
+                        "name": "maxPrice",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Поле для сортировки: id, name, price, manufacturer, category_id, stock, rating, review_count",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Продукты в заданном диапазоне цен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные значения цен (в том числе minPrice больше maxPrice) или параметров запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/stats-by-manufacturer": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает для каждого производителя количество продуктов, среднюю, минимальную и максимальную цену. Цены берутся в исходной валюте продуктов. Производители упорядочены по количеству продуктов по убыванию.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Статистика цен по производителям",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статистика по производителям",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ManufacturerStats"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Получает информацию о продукте по уникальному идентификатору. Поддерживает условный запрос через If-None-Match.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Получение продукта по ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включить удаленные продукты (только для администраторов)",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag ранее полученного ответа",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш представления продукта"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Продукт не изменился"
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет данные продукта по указанному ID. При смене category_id новая категория должна существовать.\nПоле version должно совпадать с текущей версией продукта, иначе изменения другого администратора не перезаписываются и возвращается 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Обновление продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Обновленные данные продукта",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное обновление",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Продукт изменен другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Мягко удаляет продукт по указанному ID. Продукт остается доступен в истории заказов.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Удаление продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное удаление продукта",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет только переданные поля продукта. Непереданные поля остаются без изменений, а переданные нулевые значения записываются явно.\nПоле version обязательно и должно совпадать с текущей версией продукта, иначе возвращается 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Частичное обновление продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменяемые поля продукта",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PatchProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленный продукт",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Продукт изменен другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/related": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает продукты из той же категории, исключая сам продукт, по убыванию рейтинга. Если похожих продуктов нет, возвращается пустой список.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Похожие продукты",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Максимальное количество продуктов",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Похожие продукты",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProductSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/reviews": {
+            "get": {
+                "description": "Публичный эндпоинт, авторизация не требуется. Возвращает отзывы о продукте с именами авторов и сводкой: средняя оценка и количество отзывов. Если передан валидный токен, у отзывов текущего пользователя is_mine = true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Получение отзывов продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сводка и список отзывов",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductReviewsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID продукта",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает новый отзыв. Текст отзыва обрезается по краям и ограничен REVIEW_MAX_LENGTH символами. Отзыв помечается как подтвержденная покупка, если пользователь заказывал продукт. При включенной настройке REQUIRE_VERIFIED_PURCHASE отзыв без покупки запрещен. Число отзывов одного пользователя ограничено REVIEW_RATE_LIMIT за REVIEW_RATE_WINDOW.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Создание нового отзыва",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для создания отзыва",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Отзыв успешно создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданного отзыва"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Продукт не был куплен пользователем",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много отзывов, повторите после Retry-After секунд",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/reviews/{review_id}": {
+            "get": {
+                "description": "Публичный эндпоинт, авторизация не требуется. Возвращает отзыв с именем автора, если он относится к указанному продукту. Если передан валидный токен, is_mine показывает, принадлежит ли отзыв текущему пользователю.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Получение отзыва по ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID отзыва",
+                        "name": "review_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отзыв",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Отзыв не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/variants": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает варианты продукта (вкус, фасовка) с их остатками и ценами. Если у варианта не задана цена, действует цена продукта.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Варианты продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Варианты продукта",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProductVariant"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет продукту вариант с набором атрибутов, остатком и необязательной собственной ценой.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Создание варианта продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные варианта",
+                        "name": "variant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductVariantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданный вариант",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductVariant"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/variants/{variant_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Полностью заменяет атрибуты, цену и остаток варианта. Если цена не передана, вариант продается по цене продукта.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Обновление варианта продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID варианта",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые данные варианта",
+                        "name": "variant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductVariantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленный вариант",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductVariant"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт или вариант не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Мягко удаляет вариант продукта. Вариант остается доступен в истории заказов, но больше не может быть заказан.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Удаление варианта продукта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "токен",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID варианта",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Вариант удален",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт или вариант не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет доступность базы данных. Возвращает 503, если база данных недоступна.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности сервиса",
+                "responses": {
+                    "200": {
+                        "description": "Сервис готов принимать запросы",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Обменивает действующий токен обновления на новый токен доступа. Предъявленный токен обновления отзывается и заменяется новым; повторное использование отозванного токена отзывает все сессии пользователя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Обновление токена",
+                "parameters": [
+                    {
+                        "description": "Токен обновления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новый JWT-токен и токен обновления",
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Токен обновления недействителен, истек или использован повторно",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Учетная запись деактивирована",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Невозможно создать токен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Эндпоинт для регистрации нового пользователя. Имя пользователя приводится к нижнему регистру и должно быть уникальным без учета регистра.\nЗарегистрированный пользователь всегда получает роль user: поле role в запросе игнорируется, другие роли назначает администратор через PATCH /users/{id}/role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Регистрация пользователя",
+                "parameters": [
+                    {
+                        "description": "Учетные данные пользователя (username, password, email)",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Credentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь успешно зарегистрирован",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки в полях формы, перечисленные в fields",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Невозможно зарегистрировать пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает данные пользователей с пагинацией и фильтрацией по имени и роли.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение списка всех пользователей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен авторизации",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Часть имени пользователя",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Роль пользователя",
+                        "name": "role",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список пользователей",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Получает информацию о текущем пользователе, включая его имя, email и роль. Пароль в ответе не передается.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение информации о пользователе",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос. Информация о пользователе",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserInfoResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Позволяет пользователю деактивировать свою учетную запись после подтверждения текущим паролем. Войти в нее больше нельзя, но история заказов сохраняется. Администраторы не могут деактивировать себя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Деактивация своей учетной записи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен авторизации",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Текущий пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteSelfRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Учетная запись успешно деактивирована",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Пароль не передан",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не авторизован или пароль неверен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Администратор не может удалить себя",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/email": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Позволяет авторизованному пользователю обновить свой email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Обновление email пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен авторизации",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для обновления email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email успешно обновлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает JSON-файл со всеми данными текущего пользователя: профиль без пароля, заказы с позициями и отзывы.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Выгрузка данных пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл с данными пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserDataExport"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment; filename=user-{id}-data.json"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/password": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Позволяет авторизованному пользователю изменить свой пароль, требуется указать старый и новый пароли",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Обновление пароля пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен авторизации",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для обновления пароля",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdatePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пароль успешно обновлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не авторизован или старый пароль указан неверно",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/reviewable": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает продукты из заказов текущего пользователя (кроме отмененных), на которые он еще не оставил отзыв",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Продукты, на которые пользователь может оставить отзыв",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен доступа пользователя (JWT)",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Продукты без отзыва пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры пагинации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает отзывы, оставленные текущим пользователем, с названиями продуктов, с пагинацией",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение отзывов текущего пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список отзывов пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserReviewsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры пагинации",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает количество заказов и отзывов пользователя и общую сумму оформленных заказов (статус completed) с учетом скидок. У нового пользователя все значения нулевые.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Статистика текущего пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен пользователя",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статистика пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/username": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Позволяет авторизованному пользователю обновить свое имя. Имя приводится к нижнему регистру и должно быть уникальным без учета регистра.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Обновление имени пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен авторизации",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для обновления имени пользователя",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUsernameRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Имя пользователя успешно обновлено",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя уже занято",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает данные конкретного пользователя по его ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение данных пользователя по идентификатору",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен авторизации",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Данные пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Позволяет администратору деактивировать пользователя с ролью \"user\". Заказы пользователя сохраняются. С параметром purge=true пользователь, его заказы, отзывы и ключи идемпотентности удаляются безвозвратно, а рейтинг продуктов с удаленными отзывами пересчитывается.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Деактивация или удаление пользователя с ролью \"user\"",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен авторизации",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Удалить пользователя и связанные данные безвозвратно",
+                        "name": "purge",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь успешно деактивирован или удален",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса, удаление невозможно или попытка удалить себя",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/role": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Позволяет администратору назначить пользователю любую допустимую роль (\"user\" или \"admin\"). Нельзя понизить последнего активного администратора.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Обновление роли пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен авторизации",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления роли",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль пользователя обновлена",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса, недопустимая роль или попытка изменить свою роль",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Нельзя понизить последнего активного администратора",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.AddOrderProductsRequest": {
+            "type": "object",
+            "required": [
+                "products"
+            ],
+            "properties": {
+                "products": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.ProductInOrder"
+                    }
+                }
+            }
+        },
+        "models.AdminReviewsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReviewResponse"
+                    }
+                },
+                "default_limit": {
+                    "description": "Действующие для эндпоинта размер страницы по умолчанию и наибольший размер",
+                    "type": "integer"
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "has_prev": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "max_limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ApplyCouponRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "target": {
+                    "description": "Объект действия, например user:42 или order:7",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditLog"
+                    }
+                },
+                "default_limit": {
+                    "description": "Действующие для эндпоинта размер страницы по умолчанию и наибольший размер",
+                    "type": "integer"
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "has_prev": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "max_limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Category": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "product_count": {
+                    "description": "Заполняется подзапросом при выборке списка категорий",
+                    "type": "integer"
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Product"
+                    }
+                }
+            }
+        },
+        "models.CategoryResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Category"
+                    }
+                },
+                "default_limit": {
+                    "description": "Действующие для эндпоинта размер страницы по умолчанию и наибольший размер",
+                    "type": "integer"
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "has_prev": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "max_limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CountProdutsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "manufacturer": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Coupon": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "usage_limit": {
+                    "description": "0 — без ограничений",
+                    "type": "integer"
+                },
+                "used_count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CreateOrderRequest": {
+            "type": "object",
+            "properties": {
+                "expected_total": {
+                    "description": "Сумма, которую показал клиент; если передана и не совпадает с рассчитанной сервером, заказ не создается",
+                    "type": "number"
+                },
+                "products": {
+                    "description": "Опциональный список продуктов",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductInOrder"
+                    }
+                }
+            }
+        },
+        "models.CreateReviewRequest": {
+            "type": "object",
+            "required": [
+                "rating"
+            ],
+            "properties": {
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                },
+                "review_text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Credentials": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeleteProductsRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.DeleteProductsResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "not_found": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.DeleteSelfRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "INVALID_REQUEST",
+                "VALIDATION_FAILED",
+                "UNAUTHORIZED",
+                "INVALID_CREDENTIALS",
+                "INVALID_TOKEN",
+                "TOKEN_EXPIRED",
+                "FORBIDDEN",
+                "ACCOUNT_DEACTIVATED",
+                "PURCHASE_REQUIRED",
+                "NOT_FOUND",
+                "PRODUCT_NOT_FOUND",
+                "VARIANT_NOT_FOUND",
+                "CATEGORY_NOT_FOUND",
+                "ORDER_NOT_FOUND",
+                "ORDER_ITEM_NOT_FOUND",
+                "USER_NOT_FOUND",
+                "COUPON_NOT_FOUND",
+                "REVIEW_NOT_FOUND",
+                "CONFLICT",
+                "ALREADY_EXISTS",
+                "LAST_ADMIN",
+                "INSUFFICIENT_STOCK",
+                "ORDER_LIMIT_EXCEEDED",
+                "TOTAL_MISMATCH",
+                "ORDER_CHECKED_OUT",
+                "ORDER_CANCELLED",
+                "COUPON_INVALID",
+                "COUPON_EXPIRED",
+                "COUPON_EXHAUSTED",
+                "COUPON_ALREADY_APPLIED",
+                "RATE_LIMITED",
+                "PAYLOAD_TOO_LARGE",
+                "REQUEST_TIMEOUT",
+                "SERVICE_UNAVAILABLE",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "ErrCodeInvalidRequest",
+                "ErrCodeValidationFailed",
+                "ErrCodeUnauthorized",
+                "ErrCodeInvalidCredentials",
+                "ErrCodeInvalidToken",
+                "ErrCodeTokenExpired",
+                "ErrCodeForbidden",
+                "ErrCodeAccountDeactivated",
+                "ErrCodePurchaseRequired",
+                "ErrCodeNotFound",
+                "ErrCodeProductNotFound",
+                "ErrCodeVariantNotFound",
+                "ErrCodeCategoryNotFound",
+                "ErrCodeOrderNotFound",
+                "ErrCodeOrderItemNotFound",
+                "ErrCodeUserNotFound",
+                "ErrCodeCouponNotFound",
+                "ErrCodeReviewNotFound",
+                "ErrCodeConflict",
+                "ErrCodeAlreadyExists",
+                "ErrCodeLastAdmin",
+                "ErrCodeInsufficientStock",
+                "ErrCodeOrderLimitExceeded",
+                "ErrCodeTotalMismatch",
+                "ErrCodeOrderCheckedOut",
+                "ErrCodeOrderCancelled",
+                "ErrCodeCouponInvalid",
+                "ErrCodeCouponExpired",
+                "ErrCodeCouponExhausted",
+                "ErrCodeCouponApplied",
+                "ErrCodeRateLimited",
+                "ErrCodePayloadTooLarge",
+                "ErrCodeTimeout",
+                "ErrCodeUnavailable",
+                "ErrCodeInternal"
+            ]
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Код ошибки, например, 400 или 500",
+                    "type": "integer"
+                },
+                "error_code": {
+                    "description": "Машиночитаемый код ошибки, например, PRODUCT_NOT_FOUND",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ErrorCode"
+                        }
+                    ]
+                },
+                "fields": {
+                    "description": "Ошибки отдельных полей; заполняется только при статусе 422",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "message": {
+                    "description": "Сообщение об ошибке",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "Путь к полю, например products[0].quantity",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Сообщение об ошибке поля",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ManufacturerStats": {
+            "type": "object",
+            "properties": {
+                "avg_price": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "max_price": {
+                    "type": "number"
+                },
+                "min_price": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.MergeProductRequest": {
+            "type": "object",
+            "required": [
+                "target_id"
+            ],
+            "properties": {
+                "target_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.MergeProductResponse": {
+            "type": "object",
+            "properties": {
+                "dropped_reviews": {
+                    "description": "Отзывы авторов, уже оставивших отзыв на target_id",
+                    "type": "integer"
+                },
+                "merged_order_lines": {
+                    "description": "Позиции, сложенные с уже имевшейся позицией target_id в том же заказе",
+                    "type": "integer"
+                },
+                "moved_order_lines": {
+                    "type": "integer"
+                },
+                "moved_reviews": {
+                    "type": "integer"
+                },
+                "target_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "coupon_code": {
+                    "description": "Купон, примененный к заказу; тип и размер скидки копируются, чтобы изменение купона не меняло заказ",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "discount_type": {
+                    "type": "string"
+                },
+                "discount_value": {
+                    "type": "number"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderProduct"
+                    }
+                },
+                "status": {
+                    "description": "Пока заказ в статусе pending, его позиции зарезервированы на складе; при оформлении резерв списывается,\nпри отмене (cancelled) — снимается",
+                    "type": "string"
+                },
+                "subtotal": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderLineSummary": {
+            "type": "object",
+            "properties": {
+                "line_total": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderProduct": {
+            "type": "object",
+            "properties": {
+                "order_id": {
+                    "type": "integer"
+                },
+                "price_at_purchase": {
+                    "description": "Цена единицы на момент добавления в заказ",
+                    "type": "number"
+                },
+                "product": {
+                    "$ref": "#/definitions/models.Product"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "variant": {
+                    "$ref": "#/definitions/models.ProductVariant"
+                },
+                "variant_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                },
+                "default_limit": {
+                    "description": "Действующие для эндпоинта размер страницы по умолчанию и наибольший размер",
+                    "type": "integer"
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "has_prev": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "max_limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderSummaryResponse": {
+            "type": "object",
+            "properties": {
+                "discount": {
+                    "type": "number"
+                },
+                "item_count": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderLineSummary"
+                    }
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "subtotal": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "total_quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PasswordPolicyResponse": {
+            "type": "object",
+            "properties": {
+                "min_length": {
+                    "type": "integer"
+                },
+                "require_mixed_chars": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.PatchCategoryRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "move_products_to": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PatchCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/models.Category"
+                },
+                "moved_products": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PatchProductRequest": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "image_urls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Ожидаемая текущая версия продукта, обязательна",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Product": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "description": "Сколько еще можно заказать",
+                    "type": "integer"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "description": "Код валюты ISO 4217",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_urls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "review_count": {
+                    "description": "Заполняется подзапросом при выборке продуктов",
+                    "type": "integer"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Растет при каждом изменении продукта администратором",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductInOrder": {
+            "type": "object",
+            "required": [
+                "product_id"
+            ],
+            "properties": {
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "description": "Необязательный вариант продукта",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductRequest": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "image_urls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Ожидаемая текущая версия продукта; обязательна при обновлении, при создании игнорируется",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductSummary"
+                    }
+                },
+                "default_limit": {
+                    "description": "Действующие для эндпоинта размер страницы по умолчанию и наибольший размер",
+                    "type": "integer"
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "has_prev": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "max_limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductReviewsResponse": {
+            "type": "object",
+            "properties": {
+                "reviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReviewResponse"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/models.ReviewSummary"
+                }
+            }
+        },
+        "models.ProductSales": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity_sold": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ProductSummary": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "description": "Сколько еще можно заказать с учетом резервов",
+                    "type": "integer"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "matches": {
+                    "description": "Места совпадения с поисковым запросом name в названии и описании; заполняется только при поиске",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SearchMatch"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "review_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductVariant": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "description": "Например {\"flavor\": \"шоколад\", \"weight\": \"900 г\"}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "description": "Цена варианта; если не задана, действует цена продукта",
+                    "type": "number"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "stock": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductVariantRequest": {
+            "type": "object",
+            "required": [
+                "attributes"
+            ],
+            "properties": {
+                "attributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "price": {
+                    "type": "number"
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "models.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RemovedItemsResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "removed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReorderResponse": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "$ref": "#/definitions/models.Order"
+                },
+                "skipped_products": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.RepairOrphanedProductsResponse": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReviewResponse": {
             "type": "object",
             "properties": {
-                "description": {
+                "id": {
+                    "type": "integer"
+                },
+                "is_mine": {
+                    "type": "boolean"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "review_text": {
                     "type": "string"
                 },
-                "id": {
+                "user_id": {
                     "type": "integer"
                 },
-                "name": {
+                "username": {
                     "type": "string"
                 },
-                "products": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Product"
-                    }
+                "verified": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.CountProdutsResponse": {
+        "models.ReviewSummary": {
             "type": "object",
             "properties": {
-                "count": {
-                    "type": "integer"
+                "average_rating": {
+                    "type": "number"
                 },
-                "manufacturer": {
-                    "type": "string"
+                "review_count": {
+                    "type": "integer"
                 }
             }
         },
-        "models.CreateOrderRequest": {
+        "models.SalesReportResponse": {
             "type": "object",
             "properties": {
-                "products": {
-                    "description": "Опциональный список продуктов",
+                "currency": {
+                    "type": "string"
+                },
+                "order_count": {
+                    "type": "integer"
+                },
+                "top_products": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.ProductInOrder"
+                        "$ref": "#/definitions/models.ProductSales"
                     }
+                },
+                "total_revenue": {
+                    "type": "number"
                 }
             }
         },
-        "models.CreateReviewRequest": {
+        "models.SearchMatch": {
             "type": "object",
             "properties": {
-                "rating": {
+                "end": {
                     "type": "integer"
                 },
-                "review_text": {
+                "field": {
                     "type": "string"
+                },
+                "start": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Credentials": {
+        "models.TokenResponse": {
             "type": "object",
             "properties": {
-                "password": {
+                "refresh_token": {
                     "type": "string"
                 },
-                "username": {
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "models.ErrorResponse": {
+        "models.UpdateEmailRequest": {
             "type": "object",
+            "required": [
+                "email"
+            ],
             "properties": {
-                "code": {
-                    "description": "Код ошибки, например, 400 или 500",
-                    "type": "integer"
-                },
-                "message": {
-                    "description": "Сообщение об ошибке",
+                "email": {
                     "type": "string"
                 }
             }
         },
-        "models.MessageResponse": {
+        "models.UpdateOrderProductsRequest": {
             "type": "object",
+            "required": [
+                "products"
+            ],
             "properties": {
-                "message": {
+                "products": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.ProductInOrder"
+                    }
+                }
+            }
+        },
+        "models.UpdatePasswordRequest": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "old_password"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "old_password": {
                     "type": "string"
                 }
             }
         },
-        "models.Order": {
+        "models.UpdateProductQuantityRequest": {
             "type": "object",
+            "required": [
+                "quantity"
+            ],
             "properties": {
-                "order_id": {
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "models.UpdateProductsCategoryRequest": {
+            "type": "object",
+            "required": [
+                "category_id",
+                "product_ids"
+            ],
+            "properties": {
+                "category_id": {
                     "type": "integer"
                 },
-                "products": {
+                "product_ids": {
                     "type": "array",
+                    "minItems": 1,
                     "items": {
-                        "$ref": "#/definitions/models.OrderProduct"
+                        "type": "integer"
                     }
-                },
-                "user_id": {
-                    "type": "integer"
                 }
             }
         },
-        "models.OrderProduct": {
+        "models.UpdateProductsCategoryResponse": {
             "type": "object",
             "properties": {
-                "order_id": {
-                    "type": "integer"
-                },
-                "product": {
-                    "$ref": "#/definitions/models.Product"
-                },
-                "product_id": {
-                    "type": "integer"
-                },
-                "quantity": {
+                "updated": {
                     "type": "integer"
                 }
             }
         },
-        "models.OrderResponse": {
+        "models.UpdateUserRoleRequest": {
             "type": "object",
+            "required": [
+                "role"
+            ],
             "properties": {
-                "data": {
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateUsernameRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "minLength": 2
+                }
+            }
+        },
+        "models.UserDataExport": {
+            "type": "object",
+            "properties": {
+                "exported_at": {
+                    "type": "string"
+                },
+                "orders": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.Order"
                     }
                 },
-                "limit": {
-                    "type": "integer"
-                },
-                "page": {
-                    "type": "integer"
+                "profile": {
+                    "$ref": "#/definitions/models.UserProfile"
                 },
-                "total": {
-                    "type": "integer"
+                "reviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserReviewResponse"
+                    }
                 }
             }
         },
-        "models.Product": {
+        "models.UserInfoResponse": {
             "type": "object",
             "properties": {
-                "category_id": {
-                    "type": "integer"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "manufacturer": {
+                "email": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "number"
-                },
-                "rating": {
-                    "type": "number"
+                "role": {
+                    "type": "string"
                 }
             }
         },
-        "models.ProductInOrder": {
+        "models.UserProfile": {
             "type": "object",
             "properties": {
-                "product_id": {
-                    "type": "integer"
+                "active": {
+                    "type": "boolean"
                 },
-                "quantity": {
+                "email": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "models.ProductResponse": {
+        "models.UserResponse": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Product"
+                        "$ref": "#/definitions/models.UserProfile"
                     }
                 },
+                "default_limit": {
+                    "description": "Действующие для эндпоинта размер страницы по умолчанию и наибольший размер",
+                    "type": "integer"
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "has_prev": {
+                    "type": "boolean"
+                },
                 "limit": {
                     "type": "integer"
                 },
+                "max_limit": {
+                    "type": "integer"
+                },
                 "page": {
                     "type": "integer"
                 },
                 "total": {
                     "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Review": {
+        "models.UserReviewResponse": {
             "type": "object",
             "properties": {
                 "id": {
@@ -2397,85 +6807,68 @@ const docTemplate = `{
                 "product_id": {
                     "type": "integer"
                 },
+                "product_name": {
+                    "type": "string"
+                },
                 "rating": {
                     "type": "integer"
                 },
                 "review_text": {
                     "type": "string"
                 },
-                "user_id": {
-                    "type": "integer"
+                "verified": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.TokenResponse": {
-            "type": "object",
-            "properties": {
-                "token": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.UpdatePasswordRequest": {
+        "models.UserReviewsResponse": {
             "type": "object",
             "properties": {
-                "new_password": {
-                    "type": "string"
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserReviewResponse"
+                    }
                 },
-                "old_password": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.UpdateProductQuantityRequest": {
-            "type": "object",
-            "properties": {
-                "quantity": {
+                "default_limit": {
+                    "description": "Действующие для эндпоинта размер страницы по умолчанию и наибольший размер",
                     "type": "integer"
-                }
-            }
-        },
-        "models.UpdateUserRoleRequest": {
-            "type": "object",
-            "properties": {
-                "role": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.UpdateUsernameRequest": {
-            "type": "object",
-            "properties": {
-                "username": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.User": {
-            "type": "object",
-            "properties": {
-                "id": {
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "has_prev": {
+                    "type": "boolean"
+                },
+                "limit": {
                     "type": "integer"
                 },
-                "password": {
-                    "type": "string"
+                "max_limit": {
+                    "type": "integer"
                 },
-                "role": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "username": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
                 }
             }
         },
-        "models.UserInfoResponse": {
+        "models.UserStatsResponse": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string"
+                "order_count": {
+                    "type": "integer"
                 },
-                "role": {
-                    "type": "string"
+                "review_count": {
+                    "type": "integer"
+                },
+                "total_spent": {
+                    "description": "Сумма оформленных заказов с учетом скидок",
+                    "type": "number"
                 }
             }
         }
@@ -2507,6 +6900,22 @@ const docTemplate = `{
         {
             "description": "Управление категориями",
             "name": "categories"
+        },
+        {
+            "description": "Управление купонами на скидку",
+            "name": "coupons"
+        },
+        {
+            "description": "Проверка состояния сервиса",
+            "name": "health"
+        },
+        {
+            "description": "Отчеты для администраторов",
+            "name": "reports"
+        },
+        {
+            "description": "Журнал действий администраторов",
+            "name": "audit"
         }
     ]
 }`
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
 	"project/models"
 	"project/services"
@@ -8,11 +9,18 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
+		tokenString, err := utils.ExtractBearerToken(c)
+		if err != nil {
+			utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "unauthorized")
+			c.Abort()
+			return
+		}
+
 		claims := &models.Claims{}
 
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -20,25 +28,81 @@ func AuthMiddleware() gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			if err == jwt.ErrSignatureInvalid {
-				utils.HandleError(c, http.StatusUnauthorized, "invalid token")
+			// jwt-go оборачивает ошибку подписи в ValidationError, поэтому сравнивать с jwt.ErrSignatureInvalid нельзя
+			ve, _ := err.(*jwt.ValidationError)
+			if ve != nil && ve.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+				utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeInvalidToken, "invalid token")
 				c.Abort() // Прерываем обработку запроса
 				return
 			}
 
 			// Обработка истёкшего токена
-			if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors == jwt.ValidationErrorExpired {
-				utils.HandleError(c, http.StatusUnauthorized, "token expired")
+			if ve != nil && ve.Errors == jwt.ValidationErrorExpired {
+				utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeTokenExpired, "token expired")
 				c.Abort()
 				return
 			}
 
-			utils.HandleError(c, http.StatusUnauthorized, "unauthorized")
+			utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "unauthorized")
 			c.Abort()
 			return
 		}
 
 		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware — для публичных эндпоинтов: при валидном токене
+// заполняет user_id и role, иначе пропускает запрос как анонимный
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, err := utils.ExtractBearerToken(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		claims := &models.Claims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return services.JwtKey, nil
+		})
+		if err == nil && token.Valid {
+			c.Set("user_id", claims.UserID)
+			c.Set("role", claims.Role)
+		}
+		c.Next()
+	}
+}
+
+// ActiveUserMiddleware отклоняет запросы деактивированных пользователей, не дожидаясь
+// истечения их токена доступа; ставится после AuthMiddleware
+func ActiveUserMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		var user models.User
+		if err := services.DB.WithContext(c.Request.Context()).Select("active").Where("id = ?", userID).Take(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "unauthorized")
+			} else {
+				utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "internal server error")
+			}
+			c.Abort()
+			return
+		}
+
+		if !user.Active {
+			utils.HandleError(c, http.StatusForbidden, models.ErrCodeAccountDeactivated, "account is deactivated")
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -0,0 +1,133 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"project/services"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+func signToken(t *testing.T, claims models.Claims, key []byte) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func serveAuth(authorization string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", AuthMiddleware(), func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		role, _ := c.Get("role")
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": role})
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	valid, err := services.GenerateToken(7, "alice", models.RoleAdmin)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	now := time.Now()
+	expired := signToken(t, models.Claims{UserID: 7, Role: models.RoleUser, StandardClaims: jwt.StandardClaims{
+		ExpiresAt: now.Add(-time.Hour).Unix(),
+		IssuedAt:  now.Add(-2 * time.Hour).Unix(),
+	}}, services.JwtKey)
+	forged := signToken(t, models.Claims{UserID: 7, Role: models.RoleAdmin, StandardClaims: jwt.StandardClaims{
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}}, []byte("another_key"))
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		code          models.ErrorCode
+	}{
+		{name: "bearer token", authorization: "Bearer " + valid, status: http.StatusOK},
+		{name: "lowercase scheme", authorization: "bearer " + valid, status: http.StatusOK},
+		{name: "bare token", authorization: valid, status: http.StatusOK},
+		{name: "surrounding spaces", authorization: "  Bearer   " + valid + "  ", status: http.StatusOK},
+		{name: "missing header", status: http.StatusUnauthorized, code: models.ErrCodeUnauthorized},
+		{name: "scheme without token", authorization: "Bearer", status: http.StatusUnauthorized, code: models.ErrCodeUnauthorized},
+		{name: "extra parts", authorization: "Bearer " + valid + " extra", status: http.StatusUnauthorized, code: models.ErrCodeUnauthorized},
+		{name: "other scheme", authorization: "Basic " + valid, status: http.StatusUnauthorized, code: models.ErrCodeUnauthorized},
+		{name: "garbage token", authorization: "Bearer not-a-jwt", status: http.StatusUnauthorized, code: models.ErrCodeUnauthorized},
+		{name: "foreign signature", authorization: "Bearer " + forged, status: http.StatusUnauthorized, code: models.ErrCodeInvalidToken},
+		{name: "expired token", authorization: "Bearer " + expired, status: http.StatusUnauthorized, code: models.ErrCodeTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveAuth(tt.authorization)
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", recorder.Code, tt.status, recorder.Body.String())
+			}
+
+			if tt.status == http.StatusOK {
+				var body struct {
+					UserID int    `json:"user_id"`
+					Role   string `json:"role"`
+				}
+				if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+					t.Fatalf("invalid body: %v", err)
+				}
+				if body.UserID != 7 || body.Role != models.RoleAdmin {
+					t.Fatalf("context user_id/role = %d/%s, want 7/%s", body.UserID, body.Role, models.RoleAdmin)
+				}
+				return
+			}
+
+			var body models.ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid error body: %v", err)
+			}
+			if body.ErrorCode != tt.code {
+				t.Fatalf("error_code = %s, want %s", body.ErrorCode, tt.code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareIssuedAt(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		status   int
+	}{
+		{name: "issued in the future", issuedAt: now.Add(time.Hour), status: http.StatusUnauthorized},
+		{name: "issued beyond clock skew", issuedAt: now.Add(models.ClockSkew + 5*time.Second), status: http.StatusUnauthorized},
+		{name: "issued within clock skew", issuedAt: now.Add(models.ClockSkew / 2), status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signToken(t, models.Claims{UserID: 7, Role: models.RoleAdmin, StandardClaims: jwt.StandardClaims{
+				ExpiresAt: now.Add(2 * time.Hour).Unix(),
+				IssuedAt:  tt.issuedAt.Unix(),
+			}}, services.JwtKey)
+
+			recorder := serveAuth("Bearer " + token)
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", recorder.Code, tt.status, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"project/models"
+	"project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimitMiddleware отклоняет запросы с телом больше limit байт статусом 413.
+// Для маршрутов из overrides (по шаблону пути, например "/products/bulk") действует свой лимит.
+func BodyLimitMiddleware(limit int64, overrides map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		max := limit
+		if override, ok := overrides[c.FullPath()]; ok {
+			max = override
+		}
+
+		if c.Request.ContentLength > max {
+			rejectTooLarge(c, max)
+			return
+		}
+
+		// Тело читается заранее, чтобы превышение лимита всегда давало 413, а не ошибку разбора JSON
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, max+1))
+		c.Request.Body.Close()
+		if err != nil {
+			utils.HandleError(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, "Failed to read request body")
+			c.Abort()
+			return
+		}
+		if int64(len(body)) > max {
+			rejectTooLarge(c, max)
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func rejectTooLarge(c *gin.Context, max int64) {
+	utils.HandleError(c, http.StatusRequestEntityTooLarge, models.ErrCodePayloadTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", max))
+	c.Abort()
+}
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newBodyLimitRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimitMiddleware(16, map[string]int64{"/products/bulk": 64}))
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, string(body))
+	}
+	router.POST("/products", echo)
+	router.POST("/products/bulk", echo)
+	return router
+}
+
+// sendBody отправляет тело; при chunked длина тела заранее не известна, как при Transfer-Encoding: chunked
+func sendBody(router *gin.Engine, path, body string, chunked bool) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if chunked {
+		request.ContentLength = -1
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	router := newBodyLimitRouter()
+	tests := []struct {
+		name    string
+		path    string
+		size    int
+		chunked bool
+		status  int
+	}{
+		{name: "within limit", path: "/products", size: 16, status: http.StatusOK},
+		{name: "over limit", path: "/products", size: 17, status: http.StatusRequestEntityTooLarge},
+		{name: "over limit without Content-Length", path: "/products", size: 17, chunked: true, status: http.StatusRequestEntityTooLarge},
+		{name: "within limit without Content-Length", path: "/products", size: 16, chunked: true, status: http.StatusOK},
+		{name: "route override", path: "/products/bulk", size: 64, status: http.StatusOK},
+		{name: "over route override", path: "/products/bulk", size: 65, chunked: true, status: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Repeat("a", tt.size)
+			recorder := sendBody(router, tt.path, body, tt.chunked)
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.status)
+			}
+
+			if tt.status == http.StatusOK {
+				if recorder.Body.String() != body {
+					t.Fatalf("handler read %d bytes, want %d", recorder.Body.Len(), tt.size)
+				}
+				return
+			}
+			var response models.ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid error body: %v", err)
+			}
+			if response.ErrorCode != models.ErrCodePayloadTooLarge {
+				t.Fatalf("error_code = %s, want %s", response.ErrorCode, models.ErrCodePayloadTooLarge)
+			}
+		})
+	}
+}
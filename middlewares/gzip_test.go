@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveGzip(method, acceptEncoding, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GzipMiddleware(64))
+	router.Handle(method, "/", func(c *gin.Context) {
+		c.String(http.StatusOK, body)
+	})
+
+	request := httptest.NewRequest(method, "/", nil)
+	if acceptEncoding != "" {
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGzipMiddlewareCompressesLargeResponse(t *testing.T) {
+	body := strings.Repeat("product ", 100)
+	recorder := serveGzip(http.MethodGet, "gzip", body)
+
+	if recorder.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", recorder.Header().Get("Content-Encoding"))
+	}
+	if recorder.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("Vary = %q, want Accept-Encoding", recorder.Header().Get("Vary"))
+	}
+	reader, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatalf("response is not gzip: %v", err)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	if string(decoded) != body {
+		t.Fatalf("decompressed body differs from the original")
+	}
+}
+
+func TestGzipMiddlewareNegotiation(t *testing.T) {
+	large := strings.Repeat("product ", 100)
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		body           string
+		compressed     bool
+	}{
+		{name: "no Accept-Encoding", method: http.MethodGet, body: large},
+		{name: "other encodings only", method: http.MethodGet, acceptEncoding: "deflate, br", body: large},
+		{name: "gzip refused", method: http.MethodGet, acceptEncoding: "gzip;q=0", body: large},
+		{name: "gzip refused with spaces", method: http.MethodGet, acceptEncoding: "br, gzip; q=0", body: large},
+		{name: "gzip with weight", method: http.MethodGet, acceptEncoding: "deflate, gzip;q=0.5", body: large, compressed: true},
+		{name: "short response", method: http.MethodGet, acceptEncoding: "gzip", body: "ok"},
+		{name: "HEAD request", method: http.MethodHead, acceptEncoding: "gzip", body: large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveGzip(tt.method, tt.acceptEncoding, tt.body)
+			if compressed := recorder.Header().Get("Content-Encoding") == "gzip"; compressed != tt.compressed {
+				t.Fatalf("compressed = %v, want %v", compressed, tt.compressed)
+			}
+			if !tt.compressed && tt.method != http.MethodHead && recorder.Body.String() != tt.body {
+				t.Fatalf("uncompressed body = %q, want %q", recorder.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestGzipMiddlewareKeepsPrecompressedResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GzipMiddleware(8))
+	payload := strings.Repeat("x", 100)
+	router.GET("/", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.String(http.StatusOK, payload)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept-Encoding", "gzip, br")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Header().Get("Content-Encoding") != "br" || recorder.Body.String() != payload {
+		t.Fatalf("Content-Encoding = %q; precompressed response was altered", recorder.Header().Get("Content-Encoding"))
+	}
+}
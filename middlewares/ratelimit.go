@@ -0,0 +1,140 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"project/models"
+	"project/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimiter считает запросы по ключу в фиксированных окнах времени
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	entries   map[string]*rateEntry
+	lastPrune time.Time
+}
+
+type rateEntry struct {
+	count   int
+	resetAt time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:     limit,
+		window:    window,
+		entries:   make(map[string]*rateEntry),
+		lastPrune: time.Now(),
+	}
+}
+
+// allow регистрирует попытку и возвращает, разрешена ли она, и сколько ждать до сброса окна
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+
+	entry, ok := l.entries[key]
+	if !ok || !now.Before(entry.resetAt) {
+		entry = &rateEntry{resetAt: now.Add(l.window)}
+		l.entries[key] = entry
+	}
+
+	if entry.count >= l.limit {
+		return false, entry.resetAt.Sub(now)
+	}
+	entry.count++
+	return true, 0
+}
+
+// prune удаляет истекшие окна, чтобы память не росла бесконечно
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	for key, entry := range l.entries {
+		if !now.Before(entry.resetAt) {
+			delete(l.entries, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+// LoginRateLimitMiddleware ограничивает число попыток входа с одного IP и для одного имени пользователя
+func LoginRateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
+	limiter := newRateLimiter(limit, window)
+
+	return func(c *gin.Context) {
+		keys := []string{"ip:" + c.ClientIP()}
+		if username := peekUsername(c); username != "" {
+			keys = append(keys, "user:"+strings.ToLower(username))
+		}
+
+		now := time.Now()
+		for _, key := range keys {
+			if ok, retryAfter := limiter.allow(key, now); !ok {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				utils.HandleError(c, http.StatusTooManyRequests, models.ErrCodeRateLimited, "too many login attempts")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// UserRateLimitMiddleware ограничивает число запросов авторизованного пользователя; ставится после AuthMiddleware
+func UserRateLimitMiddleware(limit int, window time.Duration, message string) gin.HandlerFunc {
+	limiter := newRateLimiter(limit, window)
+
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		if ok, retryAfter := limiter.allow("user:"+strconv.Itoa(userID.(int)), time.Now()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			utils.HandleError(c, http.StatusTooManyRequests, models.ErrCodeRateLimited, message)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// peekUsername читает имя пользователя из тела запроса, не лишая обработчик возможности прочитать тело
+func peekUsername(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Username string
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return payload.Username
+}
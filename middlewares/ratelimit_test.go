@@ -0,0 +1,210 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newLoginRouter(limit int, window time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/login", LoginRateLimitMiddleware(limit, window), func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.MessageResponse{Message: "ok"})
+	})
+	return router
+}
+
+func loginAttempt(router *gin.Engine, ip, username string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"`+username+`","password":"wrong"}`))
+	request.RemoteAddr = ip + ":1234"
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func assertRateLimited(t *testing.T, recorder *httptest.ResponseRecorder) {
+	t.Helper()
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	var body models.ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid error body: %v", err)
+	}
+	if body.ErrorCode != models.ErrCodeRateLimited {
+		t.Fatalf("error_code = %s, want %s", body.ErrorCode, models.ErrCodeRateLimited)
+	}
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Fatal("Retry-After header is missing")
+	}
+}
+
+func TestLoginRateLimitBlocksAfterLimit(t *testing.T) {
+	router := newLoginRouter(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if recorder := loginAttempt(router, "192.0.2.1", "alice"); recorder.Code != http.StatusOK {
+			t.Fatalf("attempt %d: status = %d, want %d", i+1, recorder.Code, http.StatusOK)
+		}
+	}
+
+	recorder := loginAttempt(router, "192.0.2.1", "alice")
+	assertRateLimited(t, recorder)
+	if got := recorder.Header().Get("Retry-After"); got != "60" {
+		t.Fatalf("Retry-After = %s, want 60", got)
+	}
+}
+
+func TestLoginRateLimitKeysByIP(t *testing.T) {
+	router := newLoginRouter(2, time.Minute)
+
+	// Разные имена пользователей с одного адреса упираются в лимит IP
+	loginAttempt(router, "192.0.2.1", "alice")
+	loginAttempt(router, "192.0.2.1", "bob")
+	assertRateLimited(t, loginAttempt(router, "192.0.2.1", "carol"))
+
+	if recorder := loginAttempt(router, "192.0.2.2", "dave"); recorder.Code != http.StatusOK {
+		t.Fatalf("other IP: status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+}
+
+func TestLoginRateLimitKeysByUsername(t *testing.T) {
+	router := newLoginRouter(2, time.Minute)
+
+	// Перебор пароля одного пользователя с разных адресов упирается в лимит имени без учета регистра
+	loginAttempt(router, "192.0.2.1", "alice")
+	loginAttempt(router, "192.0.2.2", "Alice")
+	assertRateLimited(t, loginAttempt(router, "192.0.2.3", "ALICE"))
+
+	if recorder := loginAttempt(router, "192.0.2.4", "bob"); recorder.Code != http.StatusOK {
+		t.Fatalf("other username: status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimiterAllowResetsAfterWindow(t *testing.T) {
+	limiter := newRateLimiter(2, time.Minute)
+	start := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.allow("key", start); !ok {
+			t.Fatalf("attempt %d rejected", i+1)
+		}
+	}
+
+	ok, retryAfter := limiter.allow("key", start.Add(20*time.Second))
+	if ok {
+		t.Fatal("attempt over the limit allowed")
+	}
+	if retryAfter != 40*time.Second {
+		t.Fatalf("retry after = %v, want %v", retryAfter, 40*time.Second)
+	}
+
+	if ok, _ := limiter.allow("key", start.Add(time.Minute)); !ok {
+		t.Fatal("attempt after the window reset rejected")
+	}
+}
+
+func TestRateLimiterPruneRemovesExpiredEntries(t *testing.T) {
+	limiter := newRateLimiter(1, time.Minute)
+	start := time.Now()
+	limiter.lastPrune = start
+
+	limiter.allow("old", start)
+	limiter.allow("recent", start.Add(30*time.Second))
+
+	// До истечения окна с последней очистки записи не трогаются
+	limiter.prune(start.Add(59 * time.Second))
+	if len(limiter.entries) != 2 {
+		t.Fatalf("%d entries before the window elapsed, want 2", len(limiter.entries))
+	}
+
+	limiter.prune(start.Add(70 * time.Second))
+	if _, ok := limiter.entries["old"]; ok {
+		t.Fatal("expired entry was not pruned")
+	}
+	if _, ok := limiter.entries["recent"]; !ok {
+		t.Fatal("active entry was pruned")
+	}
+	if !limiter.lastPrune.Equal(start.Add(70 * time.Second)) {
+		t.Fatalf("lastPrune = %v, want %v", limiter.lastPrune, start.Add(70*time.Second))
+	}
+}
+
+// newReviewRouter повторяет подключение лимита к POST /products/:id/reviews; user_id подставляется вместо AuthMiddleware
+func newReviewRouter(limit int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/products/:id/reviews", func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			id, _ := strconv.Atoi(userID)
+			c.Set("user_id", id)
+		}
+		c.Next()
+	}, UserRateLimitMiddleware(limit, time.Hour, "too many reviews, try again later"), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, models.MessageResponse{Message: "review created"})
+	})
+	return router
+}
+
+func postReview(router *gin.Engine, userID string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/products/1/reviews", strings.NewReader(`{"rating":5}`))
+	if userID != "" {
+		request.Header.Set("X-Test-User", userID)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestUserRateLimitAllowsNormalUsage(t *testing.T) {
+	router := newReviewRouter(3)
+
+	for i := 0; i < 3; i++ {
+		if recorder := postReview(router, "1"); recorder.Code != http.StatusCreated {
+			t.Fatalf("review %d: status = %d, want %d", i+1, recorder.Code, http.StatusCreated)
+		}
+	}
+	if recorder := postReview(router, "2"); recorder.Code != http.StatusCreated {
+		t.Fatalf("another user: status = %d, want %d", recorder.Code, http.StatusCreated)
+	}
+}
+
+func TestUserRateLimitBlocksAfterLimit(t *testing.T) {
+	router := newReviewRouter(2)
+	postReview(router, "1")
+	postReview(router, "1")
+
+	recorder := postReview(router, "1")
+	assertRateLimited(t, recorder)
+	if got := recorder.Header().Get("Retry-After"); got != "3600" {
+		t.Fatalf("Retry-After = %s, want 3600", got)
+	}
+	var body models.ErrorResponse
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	if body.Message != "too many reviews, try again later" {
+		t.Fatalf("message = %q, want the configured message", body.Message)
+	}
+
+	// Лимит считается по пользователю, другие пользователи не затронуты
+	if recorder := postReview(router, "2"); recorder.Code != http.StatusCreated {
+		t.Fatalf("another user: status = %d, want %d", recorder.Code, http.StatusCreated)
+	}
+}
+
+func TestUserRateLimitSkipsAnonymousRequests(t *testing.T) {
+	router := newReviewRouter(1)
+
+	for i := 0; i < 3; i++ {
+		if recorder := postReview(router, ""); recorder.Code != http.StatusCreated {
+			t.Fatalf("anonymous request %d: status = %d, want %d", i+1, recorder.Code, http.StatusCreated)
+		}
+	}
+}
@@ -12,7 +12,13 @@ import (
 
 func RoleMiddleware(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
+		tokenString, err := utils.ExtractBearerToken(c)
+		if err != nil {
+			utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "unauthorized")
+			c.Abort()
+			return
+		}
+
 		claims := &models.Claims{}
 
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -20,14 +26,14 @@ func RoleMiddleware(requiredRole string) gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			utils.HandleError(c, http.StatusUnauthorized, "unauthorized")
+			utils.HandleError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "unauthorized")
 
 			c.Abort()
 			return
 		}
 
 		if claims.Role != requiredRole {
-			utils.HandleError(c, http.StatusForbidden, "forbidden")
+			utils.HandleError(c, http.StatusForbidden, models.ErrCodeForbidden, "forbidden")
 
 			c.Abort()
 			return
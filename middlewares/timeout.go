@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"project/models"
+	"project/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware ограничивает время обработки запроса: контекст запроса отменяется через timeout.
+// Обработчики выполняют запросы к базе в контексте запроса (requestDB), поэтому по истечении срока
+// запросы прерываются; внутренняя ошибка после истечения срока и отсутствие ответа превращаются в 408.
+// Для маршрутов из overrides (по шаблону пути, например "/products/export") действует свой срок
+func TimeoutMiddleware(timeout time.Duration, overrides map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := timeout
+		if override, ok := overrides[c.FullPath()]; ok {
+			limit = override
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), limit)
+		defer cancel()
+
+		writer := &timeoutWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !writer.responded {
+			utils.HandleError(c, http.StatusRequestTimeout, models.ErrCodeTimeout, "Request timed out")
+			c.Abort()
+		}
+	}
+}
+
+// timeoutWriter запоминает, начал ли обработчик ответ. Written() здесь не подходит:
+// gzip-сжатие буферизует начало ответа и до сброса буфера ничего не пишет
+type timeoutWriter struct {
+	gin.ResponseWriter
+	responded bool
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.responded = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.responded = true
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	w.responded = true
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"project/models"
+	"project/utils"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTimeoutRouter(timeout time.Duration, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TimeoutMiddleware(timeout, nil))
+	router.GET("/", handler)
+	return router
+}
+
+func serve(router *gin.Engine) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	return recorder
+}
+
+func assertTimeout(t *testing.T, recorder *httptest.ResponseRecorder) {
+	t.Helper()
+	if recorder.Code != http.StatusRequestTimeout {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusRequestTimeout)
+	}
+	var body models.ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid error body: %v", err)
+	}
+	if body.ErrorCode != models.ErrCodeTimeout {
+		t.Fatalf("error_code = %s, want %s", body.ErrorCode, models.ErrCodeTimeout)
+	}
+}
+
+func TestTimeoutMiddlewareSlowHandlerWithoutResponse(t *testing.T) {
+	router := newTimeoutRouter(20*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+
+	assertTimeout(t, serve(router))
+}
+
+func TestTimeoutMiddlewareSlowHandlerReportingInternalError(t *testing.T) {
+	// Так отвечает обработчик, чей запрос к базе прерван по сроку
+	router := newTimeoutRouter(20*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		utils.HandleError(c, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch products")
+	})
+
+	assertTimeout(t, serve(router))
+}
+
+func TestTimeoutMiddlewareFastHandler(t *testing.T) {
+	router := newTimeoutRouter(time.Second, func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.MessageResponse{Message: "ok"})
+	})
+
+	if recorder := serve(router); recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+}
+
+func TestTimeoutMiddlewareRouteOverride(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TimeoutMiddleware(20*time.Millisecond, map[string]time.Duration{"/export": time.Second}))
+	router.GET("/export", func(c *gin.Context) {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			c.JSON(http.StatusOK, models.MessageResponse{Message: "ok"})
+		case <-c.Request.Context().Done():
+		}
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+}
+
+func TestTimeoutMiddlewareKeepsResponseSentBeforeDeadline(t *testing.T) {
+	router := newTimeoutRouter(20*time.Millisecond, func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.MessageResponse{Message: "ok"})
+		<-c.Request.Context().Done()
+	})
+
+	if recorder := serve(router); recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Действия администраторов, которые записываются в журнал аудита
+const (
+	AuditActionUserCreate              = "user.create"
+	AuditActionUserRoleUpdate          = "user.role_update"
+	AuditActionUserDeactivate          = "user.deactivate"
+	AuditActionUserDelete              = "user.delete"
+	AuditActionProductCreate           = "product.create"
+	AuditActionProductsCreate          = "products.create"
+	AuditActionProductsManufacturerSet = "products.manufacturer_update"
+	AuditActionProductMerge            = "product.merge"
+	AuditActionProductsCategorySet     = "products.category_update"
+	AuditActionProductsOrphanRepair    = "products.orphan_repair"
+	AuditActionProductDelete           = "product.delete"
+	AuditActionProductsDelete          = "products.delete"
+	AuditActionOrderDelete             = "order.delete"
+	AuditActionCouponCreate            = "coupon.create"
+	AuditActionCouponUpdate            = "coupon.update"
+	AuditActionCouponDelete            = "coupon.delete"
+	AuditActionReviewDelete            = "review.delete"
+)
+
+// AuditLog — запись журнала действий администраторов
+type AuditLog struct {
+	ID      int    `gorm:"primaryKey" json:"id"`
+	ActorID int    `gorm:"index" json:"actor_id"`
+	Action  string `gorm:"index;not null" json:"action"`
+	// Объект действия, например user:42 или order:7
+	Target    string    `json:"target"`
+	Details   StringMap `gorm:"type:jsonb" json:"details" swaggertype:"object,string"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
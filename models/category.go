@@ -4,5 +4,7 @@ type Category struct {
 	ID          int       `gorm:"primaryKey" json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	Products    []Product `gorm:"foreignKey:CategoryID" json:"products"`
+	Products    []Product `gorm:"foreignKey:CategoryID" json:"products,omitempty"`
+	// Заполняется подзапросом при выборке списка категорий
+	ProductCount int64 `gorm:"->;-:migration" json:"product_count"`
 }
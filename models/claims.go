@@ -1,6 +1,20 @@
 package models
 
-import "github.com/dgrijalva/jwt-go"
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ClockSkew — допустимое расхождение часов при проверке временных полей токена
+var ClockSkew = 30 * time.Second
+
+var (
+	errTokenExpired          = errors.New("token is expired")
+	errTokenUsedBeforeIssued = errors.New("token used before issued")
+	errTokenNotValidYet      = errors.New("token is not valid yet")
+)
 
 type Claims struct {
 	UserID   int    `json:"user_id"`
@@ -8,3 +22,30 @@ type Claims struct {
 	Role     string `json:"role"`
 	jwt.StandardClaims
 }
+
+// Valid проверяет exp, iat и nbf с учетом ClockSkew. Отсутствие iat и nbf
+// допускается для токенов, выпущенных до появления этих полей.
+func (c Claims) Valid() error {
+	now := time.Now()
+	vErr := new(jwt.ValidationError)
+
+	if !c.VerifyExpiresAt(now.Add(-ClockSkew).Unix(), true) {
+		vErr.Inner = errTokenExpired
+		vErr.Errors |= jwt.ValidationErrorExpired
+	}
+
+	if !c.VerifyIssuedAt(now.Add(ClockSkew).Unix(), false) {
+		vErr.Inner = errTokenUsedBeforeIssued
+		vErr.Errors |= jwt.ValidationErrorIssuedAt
+	}
+
+	if !c.VerifyNotBefore(now.Add(ClockSkew).Unix(), false) {
+		vErr.Inner = errTokenNotValidYet
+		vErr.Errors |= jwt.ValidationErrorNotValidYet
+	}
+
+	if vErr.Errors == 0 {
+		return nil
+	}
+	return vErr
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	CouponTypePercent = "percent"
+	CouponTypeFixed   = "fixed"
+)
+
+type Coupon struct {
+	ID         int        `gorm:"primaryKey" json:"id"`
+	Code       string     `gorm:"uniqueIndex" json:"code"`
+	Type       string     `json:"type"`
+	Value      float64    `json:"value"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	UsageLimit int        `json:"usage_limit"` // 0 — без ограничений
+	UsedCount  int        `json:"used_count"`
+}
+
+// CalculateDiscount возвращает размер скидки для суммы заказа; скидка не превышает саму сумму
+func CalculateDiscount(subtotal float64, discountType string, value float64) float64 {
+	var discount float64
+	switch discountType {
+	case CouponTypePercent:
+		discount = subtotal * value / 100
+	case CouponTypeFixed:
+		discount = value
+	}
+	discount = math.Min(discount, subtotal)
+	return math.Round(discount*100) / 100
+}
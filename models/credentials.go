@@ -1,6 +1,9 @@
 package models
 
+// Credentials — учетные данные для регистрации и входа. Поля role здесь намеренно нет,
+// чтобы роль нельзя было передать при регистрации
 type Credentials struct {
 	Username string
 	Password string
-}
\ No newline at end of file
+	Email    string
+}
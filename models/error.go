@@ -1,6 +1,56 @@
 package models
 
+// ErrorCode — машиночитаемый код ошибки, по которому клиент различает причины отказа
+type ErrorCode string
+
+const (
+	ErrCodeInvalidRequest     ErrorCode = "INVALID_REQUEST"
+	ErrCodeValidationFailed   ErrorCode = "VALIDATION_FAILED"
+	ErrCodeUnauthorized       ErrorCode = "UNAUTHORIZED"
+	ErrCodeInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
+	ErrCodeInvalidToken       ErrorCode = "INVALID_TOKEN"
+	ErrCodeTokenExpired       ErrorCode = "TOKEN_EXPIRED"
+	ErrCodeForbidden          ErrorCode = "FORBIDDEN"
+	ErrCodeAccountDeactivated ErrorCode = "ACCOUNT_DEACTIVATED"
+	ErrCodePurchaseRequired   ErrorCode = "PURCHASE_REQUIRED"
+	ErrCodeNotFound           ErrorCode = "NOT_FOUND"
+	ErrCodeProductNotFound    ErrorCode = "PRODUCT_NOT_FOUND"
+	ErrCodeVariantNotFound    ErrorCode = "VARIANT_NOT_FOUND"
+	ErrCodeCategoryNotFound   ErrorCode = "CATEGORY_NOT_FOUND"
+	ErrCodeOrderNotFound      ErrorCode = "ORDER_NOT_FOUND"
+	ErrCodeOrderItemNotFound  ErrorCode = "ORDER_ITEM_NOT_FOUND"
+	ErrCodeUserNotFound       ErrorCode = "USER_NOT_FOUND"
+	ErrCodeCouponNotFound     ErrorCode = "COUPON_NOT_FOUND"
+	ErrCodeReviewNotFound     ErrorCode = "REVIEW_NOT_FOUND"
+	ErrCodeConflict           ErrorCode = "CONFLICT"
+	ErrCodeAlreadyExists      ErrorCode = "ALREADY_EXISTS"
+	ErrCodeLastAdmin          ErrorCode = "LAST_ADMIN"
+	ErrCodeInsufficientStock  ErrorCode = "INSUFFICIENT_STOCK"
+	ErrCodeOrderLimitExceeded ErrorCode = "ORDER_LIMIT_EXCEEDED"
+	ErrCodeTotalMismatch      ErrorCode = "TOTAL_MISMATCH"
+	ErrCodeOrderCheckedOut    ErrorCode = "ORDER_CHECKED_OUT"
+	ErrCodeOrderCancelled     ErrorCode = "ORDER_CANCELLED"
+	ErrCodeCouponInvalid      ErrorCode = "COUPON_INVALID"
+	ErrCodeCouponExpired      ErrorCode = "COUPON_EXPIRED"
+	ErrCodeCouponExhausted    ErrorCode = "COUPON_EXHAUSTED"
+	ErrCodeCouponApplied      ErrorCode = "COUPON_ALREADY_APPLIED"
+	ErrCodeRateLimited        ErrorCode = "RATE_LIMITED"
+	ErrCodePayloadTooLarge    ErrorCode = "PAYLOAD_TOO_LARGE"
+	ErrCodeTimeout            ErrorCode = "REQUEST_TIMEOUT"
+	ErrCodeUnavailable        ErrorCode = "SERVICE_UNAVAILABLE"
+	ErrCodeInternal           ErrorCode = "INTERNAL_ERROR"
+)
+
 type ErrorResponse struct {
-	Code    int    `json:"code"`    // Код ошибки, например, 400 или 500
-	Message string `json:"message"` // Сообщение об ошибке
+	Code      int       `json:"code"`       // Код ошибки, например, 400 или 500
+	ErrorCode ErrorCode `json:"error_code"` // Машиночитаемый код ошибки, например, PRODUCT_NOT_FOUND
+	Message   string    `json:"message"`    // Сообщение об ошибке
+	// Ошибки отдельных полей; заполняется только при статусе 422
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError описывает ошибку одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`   // Путь к полю, например products[0].quantity
+	Message string `json:"message"` // Сообщение об ошибке поля
 }
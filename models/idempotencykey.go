@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// IdempotencyKey связывает ключ идемпотентности клиента с созданным по нему заказом
+type IdempotencyKey struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	UserID    int       `gorm:"uniqueIndex:idx_idempotency_user_key" json:"user_id"`
+	Key       string    `gorm:"uniqueIndex:idx_idempotency_user_key;size:255" json:"key"`
+	OrderID   int       `json:"order_id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
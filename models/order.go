@@ -1,8 +1,42 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusCompleted = "completed"
+	OrderStatusCancelled = "cancelled"
+)
+
 type Order struct {
-	ID       int            `gorm:"primaryKey" json:"order_id"`
-	UserID   int            `json:"user_id"`
-	Products []OrderProduct `gorm:"foreignKey:OrderID" json:"products"`
-	User     User           `json:"user" gorm:"foreignKey:UserID" swaggerignore:"true"`
+	ID        int       `gorm:"primaryKey" json:"order_id"`
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	// Пока заказ в статусе pending, его позиции зарезервированы на складе; при оформлении резерв списывается,
+	// при отмене (cancelled) — снимается
+	Status   string         `gorm:"not null;default:'pending'" json:"status"`
+	Products []OrderProduct `gorm:"foreignKey:OrderID" json:"products,omitempty"`
+	// Купон, примененный к заказу; тип и размер скидки копируются, чтобы изменение купона не меняло заказ
+	CouponCode    string  `json:"coupon_code,omitempty"`
+	DiscountType  string  `json:"discount_type,omitempty"`
+	DiscountValue float64 `json:"discount_value,omitempty"`
+	Subtotal      float64 `gorm:"-" json:"subtotal"`
+	Discount      float64 `gorm:"-" json:"discount"`
+	Total         float64 `gorm:"-" json:"total"`
+	User          User    `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// AfterFind считает итоговую сумму заказа по загруженным позициям с учетом скидки
+func (o *Order) AfterFind(tx *gorm.DB) error {
+	o.Subtotal = 0
+	for _, p := range o.Products {
+		o.Subtotal += p.UnitPrice() * float64(p.Quantity)
+	}
+	o.Discount = CalculateDiscount(o.Subtotal, o.DiscountType, o.DiscountValue)
+	o.Total = o.Subtotal - o.Discount
+	return nil
 }
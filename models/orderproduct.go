@@ -4,5 +4,20 @@ type OrderProduct struct {
 	OrderID   int `gorm:"primaryKey" json:"order_id"`
 	ProductID int `gorm:"primaryKey" json:"product_id"`
 	Quantity  int `json:"quantity"`
+	VariantID *int `json:"variant_id,omitempty"`
+	PriceAtPurchase float64 `gorm:"not null;default:0" json:"price_at_purchase"` // Цена единицы на момент добавления в заказ
 	Product   Product `gorm:"foreignKey:ProductID" json:"product"`
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
+}
+
+// UnitPrice возвращает цену единицы позиции: сохраненную цену покупки, а для старых позиций без нее —
+// цену варианта, если она задана, иначе цену продукта
+func (op OrderProduct) UnitPrice() float64 {
+	if op.PriceAtPurchase > 0 {
+		return op.PriceAtPurchase
+	}
+	if op.Variant != nil && op.Variant.Price != nil {
+		return *op.Variant.Price
+	}
+	return op.Product.Price
 }
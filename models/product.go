@@ -1,16 +1,60 @@
 package models
 
+import "gorm.io/gorm"
+
 type Product struct {
-	ID           int     `gorm:"primaryKey" json:"id"`
+	ID           int            `gorm:"primaryKey" json:"id"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	CategoryID   int            `json:"category_id"`
+	Price        float64        `json:"price"`
+	Currency     string         `gorm:"size:3" json:"currency"` // Код валюты ISO 4217
+	Manufacturer string         `json:"manufacturer"`
+	Stock        int            `json:"stock"`
+	Reserved     int            `gorm:"not null;default:0" json:"-"` // Единицы, удерживаемые неоформленными заказами
+	Available    int            `gorm:"-" json:"available"`          // Сколько еще можно заказать
+	Rating       float64        `json:"rating" grom:"default:0.0"`
+	ReviewCount  int64          `gorm:"->;-:migration" json:"review_count"` // Заполняется подзапросом при выборке продуктов
+	ImageURLs    StringList     `gorm:"type:jsonb;not null;default:'[]'" json:"image_urls" swaggertype:"array,string"`
+	Version      int            `gorm:"not null;default:1" json:"version"` // Растет при каждом изменении продукта администратором
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string"`
+}
+
+// AfterFind считает доступное для заказа количество с учетом резервов
+func (p *Product) AfterFind(tx *gorm.DB) error {
+	p.Available = p.Stock - p.Reserved
+	if p.Available < 0 {
+		p.Available = 0
+	}
+	return nil
+}
+
+// ProductSummary — краткое представление продукта для списков: без описания, из складских данных только доступное количество
+type ProductSummary struct {
+	ID           int     `json:"id"`
 	Name         string  `json:"name"`
-	Description  string  `json:"description"`
 	CategoryID   int     `json:"category_id"`
 	Price        float64 `json:"price"`
+	Currency     string  `json:"currency"`
 	Manufacturer string  `json:"manufacturer"`
-	Rating       float64 `json:"rating" grom:"default:0.0"`
+	Rating       float64 `json:"rating"`
+	ReviewCount  int64   `json:"review_count"`
+	Available    int     `json:"available"` // Сколько еще можно заказать с учетом резервов
+	// Описание выбирается только при поиске, чтобы найти в нем совпадения, и в ответ не попадает
+	Description string `json:"-"`
+	// Места совпадения с поисковым запросом name в названии и описании; заполняется только при поиске
+	Matches []SearchMatch `gorm:"-" json:"matches,omitempty"`
+}
+
+// SearchMatch — вхождение поискового запроса в поле продукта, позиции в символах: [start, end)
+type SearchMatch struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
 }
 
 type ProductInOrder struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID int  `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity"`
+	VariantID *int `json:"variant_id,omitempty"` // Необязательный вариант продукта
 }
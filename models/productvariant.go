@@ -0,0 +1,13 @@
+package models
+
+import "gorm.io/gorm"
+
+// ProductVariant описывает вариант продукта (вкус, фасовка) со своим остатком и, при необходимости, своей ценой
+type ProductVariant struct {
+	ID         int            `gorm:"primaryKey" json:"id"`
+	ProductID  int            `gorm:"index;not null" json:"product_id"`
+	Attributes StringMap      `gorm:"type:jsonb;not null;default:'{}'" json:"attributes" swaggertype:"object,string"` // Например {"flavor": "шоколад", "weight": "900 г"}
+	Price      *float64       `json:"price,omitempty"`                                                                // Цена варианта; если не задана, действует цена продукта
+	Stock      int            `json:"stock"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string"`
+}
@@ -1,27 +1,133 @@
 package models
 
 type CreateOrderRequest struct {
-	Products []ProductInOrder `json:"products,omitempty"` // Опциональный список продуктов
+	Products []ProductInOrder `json:"products,omitempty" binding:"omitempty,dive"` // Опциональный список продуктов
+	// Сумма, которую показал клиент; если передана и не совпадает с рассчитанной сервером, заказ не создается
+	ExpectedTotal *float64 `json:"expected_total,omitempty"`
+}
+
+type AddOrderProductsRequest struct {
+	Products []ProductInOrder `json:"products" binding:"required,min=1,dive"`
+}
+
+// ProductRequest содержит редактируемые поля продукта для создания и полного обновления.
+// Служебные поля (id, rating, reserved, deleted_at) задаются только сервером
+type ProductRequest struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	CategoryID   int      `json:"category_id"`
+	Price        float64  `json:"price"`
+	Currency     string   `json:"currency"`
+	Manufacturer string   `json:"manufacturer"`
+	Stock        int      `json:"stock"`
+	ImageURLs    []string `json:"image_urls"`
+	Version      int      `json:"version"` // Ожидаемая текущая версия продукта; обязательна при обновлении, при создании игнорируется
+}
+
+// Product переносит редактируемые поля запроса в модель продукта
+func (r ProductRequest) Product() Product {
+	return Product{
+		Name:         r.Name,
+		Description:  r.Description,
+		CategoryID:   r.CategoryID,
+		Price:        r.Price,
+		Currency:     r.Currency,
+		Manufacturer: r.Manufacturer,
+		Stock:        r.Stock,
+		ImageURLs:    r.ImageURLs,
+		Version:      r.Version,
+	}
+}
+
+// PatchProductRequest содержит только переданные поля продукта; nil означает "не изменять"
+type PatchProductRequest struct {
+	Name         *string   `json:"name"`
+	Description  *string   `json:"description"`
+	CategoryID   *int      `json:"category_id"`
+	Price        *float64  `json:"price"`
+	Currency     *string   `json:"currency"`
+	Manufacturer *string   `json:"manufacturer"`
+	Stock        *int      `json:"stock"`
+	ImageURLs    *[]string `json:"image_urls"`
+	Version      *int      `json:"version"` // Ожидаемая текущая версия продукта, обязательна
+}
+
+// PatchCategoryRequest содержит только переданные поля категории; nil означает "не изменять".
+// MoveProductsTo переносит все продукты категории в указанную категорию
+type PatchCategoryRequest struct {
+	Name           *string `json:"name"`
+	Description    *string `json:"description"`
+	MoveProductsTo *int    `json:"move_products_to"`
+}
+
+// ProductVariantRequest содержит данные варианта продукта; цена не обязательна
+type ProductVariantRequest struct {
+	Attributes map[string]string `json:"attributes" binding:"required,min=1"`
+	Price      *float64          `json:"price" binding:"omitempty,gt=0"`
+	Stock      int               `json:"stock" binding:"min=0"`
+}
+
+type DeleteProductsRequest struct {
+	IDs []int `json:"ids" binding:"required,min=1"`
+}
+
+// UpdateProductsCategoryRequest — продукты, которые переносятся в категорию category_id
+type UpdateProductsCategoryRequest struct {
+	ProductIDs []int `json:"product_ids" binding:"required,min=1"`
+	CategoryID int   `json:"category_id" binding:"required"`
+}
+
+// MergeProductRequest — продукт, который остается после слияния
+type MergeProductRequest struct {
+	TargetID int `json:"target_id" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ApplyCouponRequest struct {
+	Code string `json:"code" binding:"required"`
 }
 
 type UpdateProductQuantityRequest struct {
-	Quantity int `json:"quantity"`
+	Quantity int `json:"quantity" binding:"required,min=1"`
+}
+
+type UpdateOrderProductsRequest struct {
+	Products []ProductInOrder `json:"products" binding:"required,min=1,dive"`
 }
 
 type UpdateUsernameRequest struct {
-	Username string `json:"username"`
+	Username string `json:"username" binding:"required,min=2"`
+}
+
+type UpdateEmailRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type DeleteSelfRequest struct {
+	Password string `json:"password" binding:"required"`
 }
 
 type UpdatePasswordRequest struct {
-	OldPassword string `json:"old_password"`
-	NewPassword string `json:"new_password"`
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// CreateUserRequest — данные учетной записи, создаваемой администратором; роль по умолчанию "user"
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email" binding:"required"`
+	Role     string `json:"role"`
 }
 
 type UpdateUserRoleRequest struct {
-	Role string `json:"role"`
+	Role string `json:"role" binding:"required"`
 }
 
 type CreateReviewRequest struct {
 	ReviewText string `json:"review_text"`
-	Rating     int    `json:"rating"`
+	Rating     int    `json:"rating" binding:"required,min=1,max=5"`
 }
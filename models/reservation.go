@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// StockReservation удерживает единицы продукта за неоформленным заказом до ExpiresAt
+type StockReservation struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	OrderID   int       `gorm:"uniqueIndex:idx_reservation_order_product" json:"order_id"`
+	ProductID int       `gorm:"uniqueIndex:idx_reservation_order_product" json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
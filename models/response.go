@@ -1,25 +1,145 @@
 package models
 
+import "time"
+
+// Pagination содержит метаданные постраничной выдачи
+type Pagination struct {
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	TotalPages int   `json:"total_pages"`
+	HasNext    bool  `json:"has_next"`
+	HasPrev    bool  `json:"has_prev"`
+	// Действующие для эндпоинта размер страницы по умолчанию и наибольший размер
+	DefaultLimit int `json:"default_limit"`
+	MaxLimit     int `json:"max_limit"`
+}
+
 type ProductResponse struct {
-	Data  []Product `json:"data"`
-	Total int64       `json:"total"`
-	Page  int       `json:"page"`
-	Limit int       `json:"limit"`
+	Data []ProductSummary `json:"data"`
+	Pagination
+}
+
+type CategoryResponse struct {
+	Data []Category `json:"data"`
+	Pagination
 }
 
 type OrderResponse struct {
-	Data  []Order `json:"data"`
-	Total int64       `json:"total"`
-	Page  int       `json:"page"`
-	Limit int       `json:"limit"`
+	Data []Order `json:"data"`
+	Pagination
+}
+
+type UserResponse struct {
+	Data []UserProfile `json:"data"`
+	Pagination
+}
+
+type ReviewResponse struct {
+	ID         int    `json:"id"`
+	ReviewText string `json:"review_text"`
+	Rating     int    `json:"rating"`
+	Verified   bool   `json:"verified"`
+	UserID     int    `json:"user_id"`
+	Username   string `json:"username"`
+	ProductID  int    `json:"product_id"`
+	IsMine     bool   `json:"is_mine" gorm:"-"`
+}
+
+// UserReviewResponse — отзыв пользователя с названием продукта
+type UserReviewResponse struct {
+	ID          int    `json:"id"`
+	ReviewText  string `json:"review_text"`
+	Rating      int    `json:"rating"`
+	Verified    bool   `json:"verified"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+}
+
+type AdminReviewsResponse struct {
+	Data []ReviewResponse `json:"data"`
+	Pagination
+}
+
+type AuditLogResponse struct {
+	Data []AuditLog `json:"data"`
+	Pagination
+}
+
+type UserReviewsResponse struct {
+	Data []UserReviewResponse `json:"data"`
+	Pagination
+}
+
+type ReviewSummary struct {
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int64   `json:"review_count"`
+}
+
+type ProductReviewsResponse struct {
+	Summary ReviewSummary    `json:"summary"`
+	Reviews []ReviewResponse `json:"reviews"`
+}
+
+// ReorderResponse — новый заказ, скопированный из прежнего, и продукты, которые скопировать не удалось
+type ReorderResponse struct {
+	Order           Order `json:"order"`
+	SkippedProducts []int `json:"skipped_products"`
+}
+
+// ProductSales — продажи одного продукта за период
+type ProductSales struct {
+	ProductID    int     `json:"product_id"`
+	Name         string  `json:"name"`
+	QuantitySold int64   `json:"quantity_sold"`
+	Revenue      float64 `json:"revenue"`
+}
+
+// SalesReportResponse — сводка продаж за период; суммы указаны в базовой валюте
+type SalesReportResponse struct {
+	Currency     string         `json:"currency"`
+	TotalRevenue float64        `json:"total_revenue"`
+	OrderCount   int64          `json:"order_count"`
+	TopProducts  []ProductSales `json:"top_products"`
+}
+
+type OrderLineSummary struct {
+	ProductID int     `json:"product_id"`
+	VariantID *int    `json:"variant_id,omitempty"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+	LineTotal float64 `json:"line_total"`
+}
+
+type OrderSummaryResponse struct {
+	OrderID       int                `json:"order_id"`
+	ItemCount     int                `json:"item_count"`
+	TotalQuantity int                `json:"total_quantity"`
+	Subtotal      float64            `json:"subtotal"`
+	Discount      float64            `json:"discount"`
+	Total         float64            `json:"total"`
+	Lines         []OrderLineSummary `json:"lines"`
+}
+
+// DeleteProductsResponse — результат массового удаления продуктов
+type DeleteProductsResponse struct {
+	Deleted  int64 `json:"deleted"`
+	NotFound []int `json:"not_found"`
 }
 
 type MessageResponse struct {
 	Message string `json:"message"`
 }
 
+type RemovedItemsResponse struct {
+	Message string `json:"message"`
+	Removed int64  `json:"removed"`
+}
+
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type CountProdutsResponse struct {
@@ -27,7 +147,60 @@ type CountProdutsResponse struct {
 	Count        int    `json:"count"`
 }
 
+type PatchCategoryResponse struct {
+	Category      Category `json:"category"`
+	MovedProducts int64    `json:"moved_products"`
+}
+
+type UpdateProductsCategoryResponse struct {
+	Updated int64 `json:"updated"`
+}
+
+type RepairOrphanedProductsResponse struct {
+	CategoryID int   `json:"category_id"`
+	Updated    int64 `json:"updated"`
+}
+
+// MergeProductResponse — итог слияния дубликата с продуктом target_id
+type MergeProductResponse struct {
+	TargetID         int   `json:"target_id"`
+	MovedReviews     int64 `json:"moved_reviews"`
+	DroppedReviews   int64 `json:"dropped_reviews"` // Отзывы авторов, уже оставивших отзыв на target_id
+	MovedOrderLines  int64 `json:"moved_order_lines"`
+	MergedOrderLines int64 `json:"merged_order_lines"` // Позиции, сложенные с уже имевшейся позицией target_id в том же заказе
+}
+
+// ManufacturerStats — количество продуктов производителя и статистика их цен
+type ManufacturerStats struct {
+	Manufacturer string  `json:"manufacturer"`
+	Count        int     `json:"count"`
+	AvgPrice     float64 `json:"avg_price"`
+	MinPrice     float64 `json:"min_price"`
+	MaxPrice     float64 `json:"max_price"`
+}
+
+type PasswordPolicyResponse struct {
+	MinLength         int  `json:"min_length"`
+	RequireMixedChars bool `json:"require_mixed_chars"`
+}
+
 type UserInfoResponse struct {
 	Name  string `json:"name"`
+	Email string `json:"email"`
 	Role  string `json:"role"`
-}
\ No newline at end of file
+}
+
+// UserStatsResponse — сводка активности пользователя для страницы профиля
+type UserStatsResponse struct {
+	OrderCount  int64   `json:"order_count"`
+	ReviewCount int64   `json:"review_count"`
+	TotalSpent  float64 `json:"total_spent"` // Сумма оформленных заказов с учетом скидок
+}
+
+// UserDataExport — все данные пользователя, которые хранит магазин
+type UserDataExport struct {
+	ExportedAt time.Time            `json:"exported_at"`
+	Profile    UserProfile          `json:"profile"`
+	Orders     []Order              `json:"orders"`
+	Reviews    []UserReviewResponse `json:"reviews"`
+}
@@ -3,9 +3,10 @@ package models
 type Review struct {
 	ID         int     `gorm:"primaryKey" json:"id"`
 	ReviewText string  `json:"review_text"`
-	Rating     int     `json:"rating"`
+	Rating     int     `json:"rating"` // От 1 до 5, ограничено CHECK-ограничением chk_reviews_rating
+	Verified   bool    `json:"verified"`
 	UserID     int     `json:"user_id" gorm:"foreignKey:UserID"`
 	ProductID  int     `json:"product_id" gorm:"foreignKey:ProductID"`
-	Product    Product `json:"product" gorm:"foreignKey:ProductID" swaggerignore:"true"`
-	User       User    `json:"user" gorm:"foreignKey:UserID" swaggerignore:"true"`
+	Product    Product `json:"-" gorm:"foreignKey:ProductID"`
+	User       User    `json:"-" gorm:"foreignKey:UserID"`
 }
@@ -1,8 +1,42 @@
 package models
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// ValidRoles содержит роли, которые можно назначить пользователю
+var ValidRoles = map[string]bool{
+	RoleUser:  true,
+	RoleAdmin: true,
+}
+
 type User struct {
 	ID       int    `gorm:"primaryKey" json:"id"`
 	Username string `gorm:"uniqueIndex" json:"username"`
-	Password string `json:"password"`
+	Email    string `gorm:"uniqueIndex:idx_users_email,where:email <> ''" json:"email"`
+	Password string `json:"-"` // Хеш пароля никогда не попадает в ответы
+	Role     string `json:"role"`
+	// Деактивированный пользователь не может войти, но его заказы сохраняются
+	Active bool `gorm:"not null;default:true" json:"active"`
+}
+
+// UserProfile — данные пользователя, которые можно возвращать в ответах
+type UserProfile struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
 	Role     string `json:"role"`
+	Active   bool   `json:"active"`
+}
+
+// Profile возвращает представление пользователя для ответов API
+func (u User) Profile() UserProfile {
+	return UserProfile{
+		ID:       u.ID,
+		Username: u.Username,
+		Email:    u.Email,
+		Role:     u.Role,
+		Active:   u.Active,
+	}
 }
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"log"
+	"project/models"
+)
+
+// RecordAudit записывает действие администратора в журнал аудита.
+// Ошибка записи только логируется: журнал не должен срывать само действие
+func RecordAudit(actorID int, action, target string, details models.StringMap) {
+	entry := models.AuditLog{
+		ActorID: actorID,
+		Action:  action,
+		Target:  target,
+		Details: details,
+	}
+	if err := DB.Create(&entry).Error; err != nil {
+		log.Printf("Failed to record audit entry %s for %s: %v", action, target, err)
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config содержит настройки приложения, которые задаются через переменные окружения
+type Config struct {
+	// Разрешать отзывы только на купленные продукты
+	RequireVerifiedPurchase bool
+	// Стоимость bcrypt; хеши с меньшей стоимостью пересчитываются при входе
+	BcryptCost int
+	// Минимальная длина пароля
+	PasswordMinLength int
+	// Требовать в пароле строчные и заглавные буквы и цифры
+	PasswordRequireMixed bool
+	// Максимальное число попыток входа за окно LoginRateWindow
+	LoginRateLimit  int
+	LoginRateWindow time.Duration
+	// Максимальное число отзывов одного пользователя за окно ReviewRateWindow
+	ReviewRateLimit  int
+	ReviewRateWindow time.Duration
+	// Настройки пула соединений с базой данных
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	// Число попыток подключения к базе при старте и задержка перед первым повтором;
+	// каждая следующая задержка вдвое больше предыдущей
+	DBConnectAttempts   int
+	DBConnectRetryDelay time.Duration
+	// Время жизни токена доступа и допустимое расхождение часов при его проверке
+	AccessTokenTTL time.Duration
+	JWTClockSkew   time.Duration
+	// Время жизни токена обновления
+	RefreshTokenTTL time.Duration
+	// Время, в течение которого повтор запроса с тем же Idempotency-Key возвращает исходный заказ
+	IdempotencyKeyTTL time.Duration
+	// Срок резерва товаров неоформленного заказа и период очистки просроченных резервов
+	ReservationTTL           time.Duration
+	ReservationSweepInterval time.Duration
+	// Размер страницы в списках по умолчанию и максимальный; больший limit ограничивается максимумом.
+	// PageLimitOverrides задает значения для отдельных маршрутов: "/products=20:200,/admin/orders=50"
+	DefaultPageLimit   int
+	MaxPageLimit       int
+	PageLimitOverrides string
+	// Ограничения заказа: число разных продуктов и общее количество товаров
+	MaxOrderLines    int
+	MaxOrderQuantity int
+	// Максимальное время обработки запроса; потоковые выгрузки ограничены отдельно
+	RequestTimeout time.Duration
+	ExportTimeout  time.Duration
+	// Максимальный размер тела запроса в байтах; для массовых операций действует отдельный лимит
+	MaxBodyBytes     int64
+	MaxBulkBodyBytes int64
+	// Название категории, в которую переносятся продукты с удаленной категорией; создается при миграции
+	UncategorizedCategoryName string
+	// Порог остатка по умолчанию, при котором продукт считается заканчивающимся
+	LowStockThreshold int
+	// Максимальная длина текста отзыва в символах
+	ReviewMaxLength int
+	// Минимальный размер ответа в байтах, начиная с которого он сжимается gzip
+	GzipMinSize int
+	// Валюта цен продуктов по умолчанию (ISO 4217)
+	BaseCurrency string
+	// Максимальное число изображений у одного продукта
+	MaxProductImages int
+	// Настройки SMTP для уведомлений; пустой SMTPHost отключает отправку
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	NotifyAdminEmail string
+}
+
+var AppConfig = LoadConfig()
+
+func LoadConfig() Config {
+	return Config{
+		RequireVerifiedPurchase:   getEnvBool("REQUIRE_VERIFIED_PURCHASE", false),
+		BcryptCost:                getEnvInt("BCRYPT_COST", 10),
+		PasswordMinLength:         getEnvInt("PASSWORD_MIN_LENGTH", 6),
+		PasswordRequireMixed:      getEnvBool("PASSWORD_REQUIRE_MIXED", false),
+		LoginRateLimit:            getEnvInt("LOGIN_RATE_LIMIT", 5),
+		LoginRateWindow:           getEnvDuration("LOGIN_RATE_WINDOW", time.Minute),
+		ReviewRateLimit:           getEnvInt("REVIEW_RATE_LIMIT", 5),
+		ReviewRateWindow:          getEnvDuration("REVIEW_RATE_WINDOW", time.Minute),
+		DBMaxOpenConns:            getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:            getEnvInt("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetime:         getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnectAttempts:         getEnvInt("DB_CONNECT_ATTEMPTS", 5),
+		DBConnectRetryDelay:       getEnvDuration("DB_CONNECT_RETRY_DELAY", time.Second),
+		AccessTokenTTL:            getEnvDuration("ACCESS_TOKEN_TTL", 10*time.Minute),
+		JWTClockSkew:              getEnvDuration("JWT_CLOCK_SKEW", 30*time.Second),
+		RefreshTokenTTL:           getEnvDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+		IdempotencyKeyTTL:         getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		ReservationTTL:            getEnvDuration("RESERVATION_TTL", 15*time.Minute),
+		ReservationSweepInterval:  getEnvDuration("RESERVATION_SWEEP_INTERVAL", time.Minute),
+		DefaultPageLimit:          getEnvInt("DEFAULT_PAGE_LIMIT", 10),
+		MaxPageLimit:              getEnvInt("MAX_PAGE_LIMIT", 100),
+		PageLimitOverrides:        getEnv("PAGE_LIMIT_OVERRIDES", ""),
+		MaxOrderLines:             getEnvInt("MAX_ORDER_LINES", 100),
+		MaxOrderQuantity:          getEnvInt("MAX_ORDER_QUANTITY", 1000),
+		RequestTimeout:            getEnvDuration("REQUEST_TIMEOUT", 5*time.Second),
+		ExportTimeout:             getEnvDuration("EXPORT_TIMEOUT", 5*time.Minute),
+		MaxBodyBytes:              int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+		MaxBulkBodyBytes:          int64(getEnvInt("MAX_BULK_BODY_BYTES", 10<<20)),
+		UncategorizedCategoryName: getEnv("UNCATEGORIZED_CATEGORY_NAME", "Uncategorized"),
+		LowStockThreshold:         getEnvInt("LOW_STOCK_THRESHOLD", 5),
+		ReviewMaxLength:           getEnvInt("REVIEW_MAX_LENGTH", 2000),
+		GzipMinSize:               getEnvInt("GZIP_MIN_SIZE", 1024),
+		BaseCurrency:              getEnv("BASE_CURRENCY", "RUB"),
+		MaxProductImages:          getEnvInt("MAX_PRODUCT_IMAGES", 10),
+		SMTPHost:                  getEnv("SMTP_HOST", ""),
+		SMTPPort:                  getEnv("SMTP_PORT", "587"),
+		SMTPUsername:              getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:              getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:                  getEnv("SMTP_FROM", "noreply@example.com"),
+		NotifyAdminEmail:          getEnv("NOTIFY_ADMIN_EMAIL", ""),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
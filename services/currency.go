@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// RateProvider возвращает курс пересчета цены из одной валюты в другую
+type RateProvider interface {
+	Rate(from, to string) (float64, error)
+}
+
+// StaticRateProvider пересчитывает цены по фиксированной таблице курсов к базовой валюте
+type StaticRateProvider struct {
+	Base string
+	// Стоимость одной единицы валюты в базовой валюте
+	Rates map[string]float64
+}
+
+func (p StaticRateProvider) Rate(from, to string) (float64, error) {
+	fromRate, ok := p.rate(from)
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	toRate, ok := p.rate(to)
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	return fromRate / toRate, nil
+}
+
+func (p StaticRateProvider) rate(code string) (float64, bool) {
+	if code == p.Base {
+		return 1, true
+	}
+	rate, ok := p.Rates[code]
+	return rate, ok && rate > 0
+}
+
+// ExchangeRates — источник курсов валют; по умолчанию статическая таблица к рублю
+var ExchangeRates RateProvider = StaticRateProvider{
+	Base: "RUB",
+	Rates: map[string]float64{
+		"USD": 90,
+		"EUR": 98,
+		"CNY": 12.5,
+		"KZT": 0.19,
+		"BYN": 28,
+	},
+}
+
+// NormalizeCurrency приводит код валюты к верхнему регистру; пустой код означает базовую валюту
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return AppConfig.BaseCurrency
+	}
+	return code
+}
+
+// IsKnownCurrency сообщает, можно ли пересчитывать цены в указанную валюту и из нее
+func IsKnownCurrency(code string) bool {
+	_, err := ExchangeRates.Rate(NormalizeCurrency(code), AppConfig.BaseCurrency)
+	return err == nil
+}
+
+// ConvertPrice пересчитывает цену между валютами с округлением до копеек
+func ConvertPrice(amount float64, from, to string) (float64, error) {
+	rate, err := ExchangeRates.Rate(NormalizeCurrency(from), NormalizeCurrency(to))
+	if err != nil {
+		return 0, err
+	}
+	return math.Round(amount*rate*100) / 100, nil
+}
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"fmt"
 	"log"
 	"project/models"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -10,16 +12,154 @@ import (
 
 var DB *gorm.DB
 
+// UncategorizedCategoryID — ID категории для продуктов, чья категория была удалена
+var UncategorizedCategoryID int
+
+// UnaccentAvailable показывает, установлено ли в базе расширение unaccent
+var UnaccentAvailable bool
+
 func InitDB() {
 	dsn := "host=62.76.233.254 user=student password=67 dbname=new_test_store port=5432 sslmode=disable"
+	if err := ConnectDB(dsn); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// ConnectDB подключается к базе по dsn, настраивает пул и выполняет миграции.
+// Используется при старте приложения и в тестах с отдельной базой
+func ConnectDB(dsn string) error {
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = connectWithRetry(dsn, AppConfig.DBConnectAttempts, AppConfig.DBConnectRetryDelay)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database handle: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(AppConfig.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(AppConfig.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(AppConfig.DBConnMaxLifetime)
+	log.Printf("Database pool configured: max_open=%d max_idle=%d max_lifetime=%s",
+		AppConfig.DBMaxOpenConns, AppConfig.DBMaxIdleConns, AppConfig.DBConnMaxLifetime)
+
+	err = DB.AutoMigrate(&models.Category{}, &models.Product{}, &models.User{}, &models.Order{}, &models.OrderProduct{}, &models.Review{}, &models.RefreshToken{}, &models.Coupon{}, &models.IdempotencyKey{}, &models.StockReservation{}, &models.ProductVariant{}, &models.AuditLog{})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	// Продукты, созданные до появления валют, считаются ценами в базовой валюте
+	if err := DB.Model(&models.Product{}).Unscoped().
+		Where("currency IS NULL OR currency = ''").
+		Update("currency", AppConfig.BaseCurrency).Error; err != nil {
+		log.Println("Failed to backfill product currency:", err)
+	}
+
+	// Позициям, созданным до сохранения цены покупки, проставляется текущая цена
+	if err := DB.Exec(`UPDATE order_products SET price_at_purchase = COALESCE(
+		(SELECT price FROM product_variants WHERE product_variants.id = order_products.variant_id),
+		(SELECT price FROM products WHERE products.id = order_products.product_id))
+		WHERE price_at_purchase = 0`).Error; err != nil {
+		log.Println("Failed to backfill order line prices:", err)
+	}
+
+	// Оценка отзыва ограничена и в базе, чтобы обход проверок в коде не испортил рейтинг продукта.
+	// NOT VALID не проверяет уже существующие строки, поэтому старые данные не мешают миграции
+	if !DB.Migrator().HasConstraint(&models.Review{}, "chk_reviews_rating") {
+		if err := DB.Exec("ALTER TABLE reviews ADD CONSTRAINT chk_reviews_rating CHECK (rating BETWEEN 1 AND 5) NOT VALID").Error; err != nil {
+			log.Println("Failed to create review rating constraint:", err)
+		}
+	}
+
+	// Названия категорий уникальны без учета регистра
+	if err := DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_name_lower ON categories (LOWER(name))").Error; err != nil {
+		log.Println("Failed to create case-insensitive category name index:", err)
+	}
+
+	if err := EnsureUncategorizedCategory(); err != nil {
+		return err
+	}
+
+	// Имена пользователей уникальны без учета регистра; при дубликатах в старых данных индекс не создастся
+	if err := DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username))").Error; err != nil {
+		log.Println("Failed to create case-insensitive username index:", err)
+	}
+
+	// Расширение unaccent нужно для поиска без учета диакритики; без него поиск учитывает диакритику
+	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS unaccent").Error; err == nil {
+		UnaccentAvailable = true
+	} else {
+		// У пользователя может не быть прав на создание расширения, хотя оно уже установлено
+		var installed int64
+		DB.Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'unaccent'").Scan(&installed)
+		UnaccentAvailable = installed > 0
+		if !UnaccentAvailable {
+			log.Println("unaccent extension is not available, accent-insensitive search disabled:", err)
+		}
 	}
+	return nil
+}
 
-	err = DB.AutoMigrate(&models.Category{}, &models.Product{}, &models.User{}, &models.Order{}, &models.OrderProduct{}, &models.Review{})
+// EnsureUncategorizedCategory создает категорию для продуктов без категории, если ее нет,
+// и запоминает ее ID: эта категория должна существовать всегда
+func EnsureUncategorizedCategory() error {
+	var uncategorized models.Category
+	if err := DB.Where("LOWER(name) = LOWER(?)", AppConfig.UncategorizedCategoryName).
+		Attrs(models.Category{Name: AppConfig.UncategorizedCategoryName, Description: "Products whose category was removed"}).
+		FirstOrCreate(&uncategorized).Error; err != nil {
+		return fmt.Errorf("failed to ensure uncategorized category: %w", err)
+	}
+	UncategorizedCategoryID = uncategorized.ID
+	return nil
+}
+
+// CloseDB закрывает пул соединений с базой данных
+func CloseDB() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatalf("Migration failed: %v", err)
+		return err
 	}
+	return sqlDB.Close()
+}
+
+// connectWithRetry подключается к базе, повторяя попытки с экспоненциальной задержкой:
+// при запуске в контейнерах база может стать доступной позже приложения
+func connectWithRetry(dsn string, attempts int, delay time.Duration) (*gorm.DB, error) {
+	open := func() (*gorm.DB, error) {
+		return gorm.Open(postgres.Open(dsn), &gorm.Config{
+			// Ошибки драйвера переводятся в gorm.ErrDuplicatedKey и другие общие ошибки
+			TranslateError: true,
+		})
+	}
+	return retryOpen(open, attempts, delay, time.Sleep)
+}
+
+// retryOpen вызывает open до attempts раз, удваивая задержку после каждой неудачи.
+// open и sleep передаются параметрами, чтобы число попыток и задержки можно было проверить без базы
+func retryOpen(open func() (*gorm.DB, error), attempts int, delay time.Duration, sleep func(time.Duration)) (*gorm.DB, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		db, err := open()
+		if err == nil {
+			return db, nil
+		}
+		lastErr = err
+
+		if attempt == attempts {
+			break
+		}
+		log.Printf("Database connection attempt %d/%d failed: %v; retrying in %s", attempt, attempts, err, delay)
+		sleep(delay)
+		delay *= 2
+	}
+
+	return nil, fmt.Errorf("after %d attempts: %w", attempts, lastErr)
 }
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeOpen падает failures раз подряд, затем возвращает соединение, и считает вызовы
+type fakeOpen struct {
+	failures int
+	calls    int
+}
+
+var errUnavailable = errors.New("connection refused")
+
+func (f *fakeOpen) open() (*gorm.DB, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errUnavailable
+	}
+	return &gorm.DB{}, nil
+}
+
+func TestRetryOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		attempts  int
+		failures  int
+		wantCalls int
+		wantDelay []time.Duration
+		wantErr   bool
+	}{
+		{name: "first attempt succeeds", attempts: 3, failures: 0, wantCalls: 1},
+		{name: "succeeds after retries", attempts: 5, failures: 2, wantCalls: 3,
+			wantDelay: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}},
+		{name: "all attempts fail", attempts: 4, failures: 10, wantCalls: 4, wantErr: true,
+			wantDelay: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}},
+		{name: "non-positive attempts make one try", attempts: 0, failures: 10, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeOpen{failures: tt.failures}
+			var slept []time.Duration
+			sleep := func(d time.Duration) { slept = append(slept, d) }
+
+			db, err := retryOpen(fake.open, tt.attempts, 100*time.Millisecond, sleep)
+
+			if fake.calls != tt.wantCalls {
+				t.Fatalf("open called %d times, want %d", fake.calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(slept, tt.wantDelay) {
+				t.Fatalf("delays = %v, want %v", slept, tt.wantDelay)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, errUnavailable) || db != nil {
+					t.Fatalf("db, err = %v, %v; want nil and the last open error", db, err)
+				}
+				return
+			}
+			if err != nil || db == nil {
+				t.Fatalf("db, err = %v, %v; want a connection", db, err)
+			}
+		})
+	}
+}
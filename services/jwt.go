@@ -10,13 +10,15 @@ import (
 var JwtKey = []byte("my_secret_key")
 
 func GenerateToken(user_id int, username string, role string) (string, error) {
-	expirationTime := time.Now().Add(10 * time.Minute)
+	now := time.Now()
 	claims := &models.Claims{
 		UserID:   user_id,
 		Username: username,
 		Role:     role,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			ExpiresAt: now.Add(AppConfig.AccessTokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"project/models"
+	"strings"
+)
+
+// Message описывает уведомление, отправляемое пользователям или владельцу магазина
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Notifier отправляет уведомления; реализация выбирается в InitNotifier
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// NoopNotifier ничего не отправляет и используется, когда SMTP не настроен
+type NoopNotifier struct{}
+
+func (NoopNotifier) Send(msg Message) error {
+	return nil
+}
+
+// SMTPNotifier отправляет уведомления по электронной почте
+type SMTPNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (n SMTPNotifier) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		n.From, strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+
+	return smtp.SendMail(net.JoinHostPort(n.Host, n.Port), auth, n.From, msg.To, []byte(body))
+}
+
+var OrderNotifier Notifier = NoopNotifier{}
+
+// InitNotifier включает отправку писем, если в конфигурации указан SMTP-сервер
+func InitNotifier() {
+	if AppConfig.SMTPHost == "" {
+		log.Println("SMTP is not configured, notifications are disabled")
+		return
+	}
+
+	OrderNotifier = SMTPNotifier{
+		Host:     AppConfig.SMTPHost,
+		Port:     AppConfig.SMTPPort,
+		Username: AppConfig.SMTPUsername,
+		Password: AppConfig.SMTPPassword,
+		From:     AppConfig.SMTPFrom,
+	}
+	log.Println("SMTP notifications enabled via", AppConfig.SMTPHost)
+}
+
+// NotifyOrderPlaced отправляет покупателю и владельцу магазина сводку по новому заказу.
+// Отправка идет в фоне: ошибки только логируются и не влияют на ответ клиенту.
+func NotifyOrderPlaced(order models.Order) {
+	var recipients []string
+
+	var user models.User
+	if err := DB.Select("email").First(&user, order.UserID).Error; err == nil && user.Email != "" {
+		recipients = append(recipients, user.Email)
+	}
+	if AppConfig.NotifyAdminEmail != "" {
+		recipients = append(recipients, AppConfig.NotifyAdminEmail)
+	}
+
+	msg := Message{
+		To:      recipients,
+		Subject: fmt.Sprintf("Order #%d placed", order.ID),
+		Body:    orderSummaryText(order),
+	}
+
+	notifier := OrderNotifier
+	go func() {
+		if err := notifier.Send(msg); err != nil {
+			log.Printf("Failed to send notification for order %d: %v", order.ID, err)
+		}
+	}()
+}
+
+func orderSummaryText(order models.Order) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Order #%d\n\n", order.ID)
+	for _, p := range order.Products {
+		fmt.Fprintf(&b, "%s x%d — %.2f\n", p.Product.Name, p.Quantity, p.UnitPrice()*float64(p.Quantity))
+	}
+	fmt.Fprintf(&b, "\nTotal: %.2f\n", order.Total)
+	return b.String()
+}
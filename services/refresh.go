@@ -0,0 +1,126 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"project/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrAccountDeactivated  = errors.New("account is deactivated")
+)
+
+// IssueRefreshToken создает новый токен обновления для пользователя и сохраняет его хеш
+func IssueRefreshToken(db *gorm.DB, userID int) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	record := models.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: time.Now().Add(AppConfig.RefreshTokenTTL),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RotateRefreshToken отзывает предъявленный токен и выдает вместо него новый.
+// Повторное предъявление уже отозванного токена считается кражей: отзываются все токены пользователя.
+// Для деактивированного пользователя возвращается ErrAccountDeactivated, токен остается нетронутым.
+func RotateRefreshToken(token string) (models.User, string, error) {
+	var user models.User
+	var newToken string
+	reusedBy := 0
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var record models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashRefreshToken(token)).
+			First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		now := time.Now()
+		if record.RevokedAt != nil {
+			reusedBy = record.UserID
+			if err := revokeUserRefreshTokens(tx, record.UserID, now); err != nil {
+				return err
+			}
+			return nil
+		}
+		if now.After(record.ExpiresAt) {
+			return ErrRefreshTokenExpired
+		}
+
+		if err := tx.First(&user, record.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+		// Деактивированному пользователю токен не ротируется: транзакция откатывается
+		if !user.Active {
+			return ErrAccountDeactivated
+		}
+
+		if err := tx.Model(&record).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		var err error
+		newToken, err = IssueRefreshToken(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return models.User{}, "", err
+	}
+	if reusedBy != 0 {
+		log.Printf("Refresh token reuse detected for user %d, all sessions revoked", reusedBy)
+		return models.User{}, "", ErrRefreshTokenReused
+	}
+	return user, newToken, nil
+}
+
+// RevokeRefreshToken отзывает токен обновления (выход из системы)
+func RevokeRefreshToken(token string) error {
+	result := DB.Model(&models.RefreshToken{}).
+		Where("token_hash = ? AND revoked_at IS NULL", hashRefreshToken(token)).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRefreshTokenInvalid
+	}
+	return nil
+}
+
+func revokeUserRefreshTokens(db *gorm.DB, userID int, now time.Time) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
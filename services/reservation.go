@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"project/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// SetReservation приводит резерв продукта за заказом к quantity единиц и продлевает срок резерва.
+// Нулевое количество снимает резерв. Вызывается внутри транзакции.
+func SetReservation(tx *gorm.DB, orderID, productID, quantity int) error {
+	var reservation models.StockReservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND product_id = ?", orderID, productID).
+		First(&reservation).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	found := err == nil
+
+	delta := quantity - reservation.Quantity
+	if delta > 0 {
+		// Условное обновление не даст двум заказам зарезервировать одну и ту же единицу
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND stock - reserved >= ?", productID, delta).
+			Update("reserved", gorm.Expr("reserved + ?", delta))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+	} else if delta < 0 {
+		if err := releaseReserved(tx, productID, -delta); err != nil {
+			return err
+		}
+	}
+
+	if quantity == 0 {
+		if found {
+			return tx.Delete(&reservation).Error
+		}
+		return nil
+	}
+
+	reservation.OrderID = orderID
+	reservation.ProductID = productID
+	reservation.Quantity = quantity
+	reservation.ExpiresAt = time.Now().Add(AppConfig.ReservationTTL)
+	return tx.Save(&reservation).Error
+}
+
+// ReleaseOrderReservations снимает все резервы заказа
+func ReleaseOrderReservations(tx *gorm.DB, orderID int) error {
+	var reservations []models.StockReservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderID).Find(&reservations).Error; err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if err := releaseReserved(tx, reservation.ProductID, reservation.Quantity); err != nil {
+			return err
+		}
+		if err := tx.Delete(&reservation).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CommitOrderReservations списывает зарезервированные единицы со склада при оформлении заказа
+func CommitOrderReservations(tx *gorm.DB, orderID int) error {
+	var reservations []models.StockReservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderID).Find(&reservations).Error; err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if err := tx.Model(&models.Product{}).Unscoped().
+			Where("id = ?", reservation.ProductID).
+			Updates(map[string]interface{}{
+				"stock":    gorm.Expr("stock - ?", reservation.Quantity),
+				"reserved": gorm.Expr("reserved - ?", reservation.Quantity),
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&reservation).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreOrderStock возвращает на склад товары оформленного заказа при его отмене:
+// остаток продуктов и вариантов увеличивается на количество в позициях
+func RestoreOrderStock(tx *gorm.DB, orderID int) error {
+	var lines []models.OrderProduct
+	if err := tx.Where("order_id = ?", orderID).Find(&lines).Error; err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if err := tx.Model(&models.Product{}).Unscoped().
+			Where("id = ?", line.ProductID).
+			Update("stock", gorm.Expr("stock + ?", line.Quantity)).Error; err != nil {
+			return err
+		}
+		if line.VariantID == nil {
+			continue
+		}
+		if err := tx.Model(&models.ProductVariant{}).Unscoped().
+			Where("id = ?", *line.VariantID).
+			Update("stock", gorm.Expr("stock + ?", line.Quantity)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations возвращает на склад просроченные резервы и сообщает, сколько их было снято
+func ReleaseExpiredReservations() (int, error) {
+	var ids []int
+	if err := DB.Model(&models.StockReservation{}).Where("expires_at < ?", time.Now()).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, id := range ids {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			// Резерв могли продлить или снять после выборки, поэтому проверяем срок повторно под блокировкой
+			var reservation models.StockReservation
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND expires_at < ?", id, time.Now()).
+				First(&reservation).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+
+			if err := releaseReserved(tx, reservation.ProductID, reservation.Quantity); err != nil {
+				return err
+			}
+			if err := tx.Delete(&reservation).Error; err != nil {
+				return err
+			}
+			released++
+			return nil
+		})
+		if err != nil {
+			return released, err
+		}
+	}
+	return released, nil
+}
+
+// StartReservationSweeper периодически снимает просроченные резервы до отмены ctx
+func StartReservationSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				released, err := ReleaseExpiredReservations()
+				if err != nil {
+					log.Println("Error releasing expired reservations:", err)
+				}
+				if released > 0 {
+					log.Printf("Released %d expired stock reservations", released)
+				}
+			}
+		}
+	}()
+}
+
+// releaseReserved уменьшает счетчик резерва продукта, в том числе мягко удаленного
+func releaseReserved(tx *gorm.DB, productID, quantity int) error {
+	return tx.Model(&models.Product{}).Unscoped().
+		Where("id = ?", productID).
+		Update("reserved", gorm.Expr("GREATEST(reserved - ?, 0)", quantity)).Error
+}
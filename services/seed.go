@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"project/models"
+	"project/utils"
+
+	"gorm.io/gorm"
+)
+
+type seedProduct struct {
+	Category string
+	Product  models.Product
+}
+
+var seedCategories = []models.Category{
+	{Name: "Протеин", Description: "Сывороточный, казеиновый и растительный протеин"},
+	{Name: "Аминокислоты", Description: "BCAA, глютамин и комплексы аминокислот"},
+	{Name: "Витамины", Description: "Витаминно-минеральные комплексы"},
+}
+
+var seedProducts = []seedProduct{
+	{"Протеин", models.Product{Name: "Whey Protein 900 г", Description: "Сывороточный протеин, вкус шоколад", Price: 2990, Manufacturer: "Optimum Nutrition", Stock: 25}},
+	{"Протеин", models.Product{Name: "Casein 900 г", Description: "Мицеллярный казеин, вкус ваниль", Price: 3290, Manufacturer: "Optimum Nutrition", Stock: 10}},
+	{"Протеин", models.Product{Name: "Soy Protein 750 г", Description: "Соевый изолят без вкуса", Price: 1890, Manufacturer: "Geneticlab", Stock: 30}},
+	{"Аминокислоты", models.Product{Name: "BCAA 2:1:1 200 г", Description: "Порошок, вкус арбуз", Price: 1490, Manufacturer: "Geneticlab", Stock: 40}},
+	{"Аминокислоты", models.Product{Name: "Glutamine 300 г", Description: "L-глютамин без вкуса", Price: 1190, Manufacturer: "Scitec Nutrition", Stock: 20}},
+	{"Витамины", models.Product{Name: "Multivitamin 60 таб.", Description: "Комплекс витаминов и минералов", Price: 990, Manufacturer: "Scitec Nutrition", Stock: 50}},
+}
+
+// Seed заполняет базу демонстрационными данными для разработки.
+// Повторный запуск ничего не дублирует: существующие записи пропускаются.
+func Seed(adminPassword, userPassword string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		categoryIDs := map[string]int{}
+		for _, seed := range seedCategories {
+			category := seed
+			if err := tx.Where(models.Category{Name: seed.Name}).FirstOrCreate(&category).Error; err != nil {
+				return err
+			}
+			categoryIDs[category.Name] = category.ID
+		}
+
+		var products []models.Product
+		for _, seed := range seedProducts {
+			product := seed.Product
+			product.CategoryID = categoryIDs[seed.Category]
+			product.Currency = AppConfig.BaseCurrency
+			if err := tx.Where(models.Product{Name: product.Name}).FirstOrCreate(&product).Error; err != nil {
+				return err
+			}
+			products = append(products, product)
+		}
+
+		if _, err := seedUser(tx, "admin", "admin@example.com", adminPassword, models.RoleAdmin); err != nil {
+			return err
+		}
+		user, err := seedUser(tx, "demo", "demo@example.com", userPassword, models.RoleUser)
+		if err != nil {
+			return err
+		}
+
+		// Демонстрационный заказ создается, только если у пользователя еще нет заказов
+		var orders int64
+		if err := tx.Model(&models.Order{}).Where("user_id = ?", user.ID).Count(&orders).Error; err != nil {
+			return err
+		}
+		if orders > 0 {
+			log.Println("Seed: demo order already exists, skipping")
+			return nil
+		}
+
+		order := models.Order{UserID: user.ID, Status: models.OrderStatusCompleted}
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		for _, product := range products[:2] {
+			if err := tx.Create(&models.OrderProduct{OrderID: order.ID, ProductID: product.ID, Quantity: 1, PriceAtPurchase: product.Price}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// seedUser возвращает существующего пользователя с таким именем или создает нового
+func seedUser(tx *gorm.DB, username, email, password, role string) (models.User, error) {
+	var user models.User
+	err := tx.Where("LOWER(username) = ?", username).First(&user).Error
+	if err == nil {
+		log.Printf("Seed: user %q already exists, skipping", username)
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return user, err
+	}
+
+	user = models.User{
+		Username: username,
+		Email:    email,
+		Password: hashedPassword,
+		Role:     role,
+	}
+	return user, tx.Create(&user).Error
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"project/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeductVariantStock списывает quantity единиц с остатка варианта при оформлении заказа.
+// Вызывается внутри транзакции. Строка варианта блокируется до конца транзакции, поэтому параллельные
+// оформления проверяют остаток по очереди; при нехватке возвращается ErrInsufficientStock,
+// для удаленного варианта — gorm.ErrRecordNotFound
+func DeductVariantStock(tx *gorm.DB, variantID, quantity int) error {
+	var variant models.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&variant, variantID).Error; err != nil {
+		return err
+	}
+	if variant.Stock < quantity {
+		return ErrInsufficientStock
+	}
+	return tx.Model(&variant).Update("stock", gorm.Expr("stock - ?", quantity)).Error
+}
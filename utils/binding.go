@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// В сообщениях об ошибках используем имена полей из json-тегов, а не имена полей структуры
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// BindAndValidate разбирает JSON-тело запроса и проверяет теги binding.
+// Ошибки валидации возвращаются как FieldErrors со всеми неверными полями.
+func BindAndValidate(c *gin.Context, obj interface{}) error {
+	if err := c.ShouldBindWith(obj, binding.JSON); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			var fieldErrs FieldErrors
+			for _, fe := range validationErrs {
+				fieldErrs.Add(fieldPath(fe), describeFieldError(fe))
+			}
+			return fieldErrs
+		}
+		return describeJSONError(err)
+	}
+	return nil
+}
+
+// describeFieldError формирует сообщение об ошибке одного поля
+func describeFieldError(fe validator.FieldError) string {
+	field := fieldPath(fe)
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("Field '%s' is required", field)
+	case "min", "gte":
+		return fmt.Sprintf("Field '%s' must be at least %s", field, fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("Field '%s' must be at most %s", field, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("Field '%s' must be one of: %s", field, fe.Param())
+	case "email":
+		return fmt.Sprintf("Field '%s' must be a valid email", field)
+	default:
+		return fmt.Sprintf("Field '%s' is invalid", field)
+	}
+}
+
+// fieldPath возвращает путь к полю без имени корневой структуры, например "products[0].product_id"
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// BindJSONStrict разбирает тело запроса в obj и отклоняет неизвестные поля,
+// чтобы опечатки в ключах (например, "prce") не проходили незамеченными
+func BindJSONStrict(c *gin.Context, obj interface{}) error {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(obj); err != nil {
+		return describeJSONError(err)
+	}
+	return nil
+}
+
+func describeJSONError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		// Пустое тело отличаем от испорченного JSON: клиент, скорее всего, просто забыл его передать
+		return errors.New("request body is required")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("malformed JSON: unexpected end of input")
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("field '%s' has invalid type", typeErr.Field)
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("malformed JSON at position %d: %s", syntaxErr.Offset, syntaxErr.Error())
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), "\"")
+		return fmt.Errorf("unknown field '%s'", field)
+	default:
+		return errors.New("malformed JSON")
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func bindStrict(body string) error {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+	var payload struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+	return BindJSONStrict(c, &payload)
+}
+
+func TestBindJSONStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string // префикс сообщения об ошибке; пустая строка — ошибки нет
+	}{
+		{name: "valid body", body: `{"name":"Milk","price":1.5}`},
+		{name: "empty body", body: ``, want: "request body is required"},
+		{name: "whitespace only", body: "  \n", want: "request body is required"},
+		{name: "truncated JSON", body: `{"name":"Milk"`, want: "malformed JSON: unexpected end of input"},
+		{name: "syntax error", body: `{"name":}`, want: "malformed JSON at position"},
+		{name: "unknown field", body: `{"name":"Milk","prce":1.5}`, want: "unknown field 'prce'"},
+		{name: "wrong type", body: `{"name":"Milk","price":"cheap"}`, want: "field 'price' has invalid type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bindStrict(tt.body)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseDateRange читает необязательные параметры from и to в формате RFC3339.
+// Отсутствующая граница возвращается как nil.
+func ParseDateRange(c *gin.Context) (from *time.Time, to *time.Time, err error) {
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, errors.New("Invalid 'from' date, expected RFC3339")
+		}
+		from = &parsed
+	}
+
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, errors.New("Invalid 'to' date, expected RFC3339")
+		}
+		to = &parsed
+	}
+
+	if from != nil && to != nil && from.After(*to) {
+		return nil, nil, errors.New("'from' must not be after 'to'")
+	}
+	return from, to, nil
+}
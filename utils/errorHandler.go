@@ -1,14 +1,23 @@
 package utils
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"project/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func HandleError(c *gin.Context, statusCode int, message string) {
+// HandleError отвечает клиенту ошибкой. Внутренняя ошибка после истечения срока запроса
+// почти всегда вызвана прерванным запросом к базе, поэтому клиент получает 408
+func HandleError(c *gin.Context, statusCode int, errorCode models.ErrorCode, message string) {
+	if statusCode == http.StatusInternalServerError && c.Request != nil && errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		statusCode, errorCode, message = http.StatusRequestTimeout, models.ErrCodeTimeout, "Request timed out"
+	}
 	c.JSON(statusCode, models.ErrorResponse{
 		Code:		statusCode,
+		ErrorCode:	errorCode,
 		Message:	message,
 	})
 }
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveETag(obj interface{}, ifNoneMatch string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		JSONWithETag(c, obj)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestJSONWithETag(t *testing.T) {
+	obj := gin.H{"id": 1, "name": "Milk"}
+	first := serveETag(obj, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q; want 200 with an ETag", first.Code, etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{name: "matching tag", ifNoneMatch: etag, status: http.StatusNotModified},
+		{name: "weak matching tag", ifNoneMatch: "W/" + etag, status: http.StatusNotModified},
+		{name: "tag in a list", ifNoneMatch: `"stale", ` + etag, status: http.StatusNotModified},
+		{name: "wildcard", ifNoneMatch: "*", status: http.StatusNotModified},
+		{name: "stale tag", ifNoneMatch: `"stale"`, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveETag(obj, tt.ifNoneMatch)
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if recorder.Header().Get("ETag") != etag {
+				t.Fatalf("ETag = %q, want %q", recorder.Header().Get("ETag"), etag)
+			}
+			if tt.status == http.StatusNotModified && recorder.Body.Len() != 0 {
+				t.Fatalf("304 response has a body: %q", recorder.Body.String())
+			}
+		})
+	}
+
+	if changed := serveETag(gin.H{"id": 1, "name": "Bread"}, etag); changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Fatalf("changed object: status = %d, ETag = %q; want 200 with a new ETag", changed.Code, changed.Header().Get("ETag"))
+	}
+}
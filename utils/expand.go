@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseExpand читает параметр expand — список связей через запятую, которые нужно подгрузить в ответ.
+// Связи вне allowed считаются ошибкой
+func ParseExpand(c *gin.Context, allowed ...string) (map[string]bool, error) {
+	expand := map[string]bool{}
+
+	raw := strings.TrimSpace(c.Query("expand"))
+	if raw == "" {
+		return expand, nil
+	}
+
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, a := range allowed {
+			if name == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("Unknown expand value '%s'", name)
+		}
+		expand[name] = true
+	}
+	return expand, nil
+}
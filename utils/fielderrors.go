@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"project/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldErrors накапливает ошибки отдельных полей, чтобы вернуть клиенту все сразу, а не только первую
+type FieldErrors []models.FieldError
+
+// Add добавляет ошибку поля
+func (e *FieldErrors) Add(field, message string) {
+	*e = append(*e, models.FieldError{Field: field, Message: message})
+}
+
+// Err возвращает nil, если ошибок нет, иначе сам список как error
+func (e FieldErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// HandleValidationError отвечает 422 со списком ошибок полей, если err их содержит, иначе 400
+func HandleValidationError(c *gin.Context, err error) {
+	var fieldErrs FieldErrors
+	if !errors.As(err, &fieldErrs) {
+		HandleError(c, http.StatusBadRequest, models.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+		Code:      http.StatusUnprocessableEntity,
+		ErrorCode: models.ErrCodeValidationFailed,
+		Message:   fieldErrs.Error(),
+		Fields:    fieldErrs,
+	})
+}
@@ -0,0 +1,42 @@
+package utils
+
+import "unicode"
+
+// FindMatches возвращает позиции всех непересекающихся вхождений query в text без учета регистра.
+// Позиции считаются в символах (рунах): [начало, конец)
+func FindMatches(text, query string) [][2]int {
+	haystack := foldRunes(text)
+	needle := foldRunes(query)
+	if len(needle) == 0 {
+		return nil
+	}
+
+	var matches [][2]int
+	for i := 0; i+len(needle) <= len(haystack); {
+		if runesEqual(haystack[i:i+len(needle)], needle) {
+			matches = append(matches, [2]int{i, i + len(needle)})
+			i += len(needle)
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+// foldRunes приводит каждую руну к нижнему регистру по отдельности, чтобы позиции совпадали с исходным текстом
+func foldRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  [][2]int
+	}{
+		{name: "single match", text: "Chocolate milk", query: "milk", want: [][2]int{{10, 14}}},
+		{name: "case insensitive", text: "Milk and MILK", query: "milk", want: [][2]int{{0, 4}, {9, 13}}},
+		{name: "positions in runes", text: "Молоко и молоко", query: "молоко", want: [][2]int{{0, 6}, {9, 15}}},
+		{name: "non-overlapping", text: "aaaa", query: "aa", want: [][2]int{{0, 2}, {2, 4}}},
+		{name: "no match", text: "Bread", query: "milk"},
+		{name: "empty query", text: "Bread", query: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindMatches(tt.text, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("FindMatches(%q, %q) = %v, want %v", tt.text, tt.query, got, tt.want)
+			}
+		})
+	}
+}
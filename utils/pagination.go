@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"project/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageLimits — размер страницы по умолчанию и наибольший допустимый размер
+type PageLimits struct {
+	Default int
+	Max     int
+}
+
+// DefaultPageLimits действуют для эндпоинтов без собственных настроек, задаются при старте приложения
+var DefaultPageLimits = PageLimits{Default: 10, Max: 100}
+
+// PageLimitOverrides — настройки страниц для отдельных маршрутов; ключ — шаблон маршрута gin, например /admin/orders
+var PageLimitOverrides = map[string]PageLimits{}
+
+// PageLimitsFor возвращает настройки страниц для маршрута текущего запроса
+func PageLimitsFor(c *gin.Context) PageLimits {
+	if limits, ok := PageLimitOverrides[c.FullPath()]; ok {
+		return limits
+	}
+	return DefaultPageLimits
+}
+
+// ParsePageLimitOverrides разбирает настройки вида "/products=20:200,/admin/orders=50".
+// Если максимум не указан, используется общий DefaultPageLimits.Max
+func ParsePageLimitOverrides(value string) (map[string]PageLimits, error) {
+	overrides := map[string]PageLimits{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, limitsValue, ok := strings.Cut(entry, "=")
+		if !ok || route == "" {
+			return nil, fmt.Errorf("invalid page limit override %q", entry)
+		}
+
+		limits := PageLimits{Max: DefaultPageLimits.Max}
+		defaultValue, maxValue, hasMax := strings.Cut(limitsValue, ":")
+
+		var err error
+		if limits.Default, err = strconv.Atoi(defaultValue); err != nil || limits.Default < 1 {
+			return nil, fmt.Errorf("invalid default page limit in %q", entry)
+		}
+		if hasMax {
+			if limits.Max, err = strconv.Atoi(maxValue); err != nil || limits.Max < 1 {
+				return nil, fmt.Errorf("invalid max page limit in %q", entry)
+			}
+		}
+		if limits.Default > limits.Max {
+			return nil, fmt.Errorf("default page limit exceeds max in %q", entry)
+		}
+
+		overrides[route] = limits
+	}
+	return overrides, nil
+}
+
+// ParsePagination читает параметры page и limit из запроса.
+// Нечисловые значения и page < 1 считаются ошибкой, limit = 0 заменяется
+// значением по умолчанию для маршрута, а слишком большой limit ограничивается его максимумом.
+func ParsePagination(c *gin.Context) (page int, limit int, err error) {
+	limits := PageLimitsFor(c)
+
+	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, errors.New("Incorrect page number")
+	}
+
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(limits.Default)))
+	if err != nil || limit < 0 {
+		return 0, 0, errors.New("Incorrect limit")
+	}
+
+	switch {
+	case limit == 0:
+		limit = limits.Default
+	case limit > limits.Max:
+		limit = limits.Max
+	}
+
+	return page, limit, nil
+}
+
+// NewPagination рассчитывает метаданные страницы по общему числу записей
+// и добавляет действующие для маршрута настройки размера страницы
+func NewPagination(c *gin.Context, total int64, page, limit int) models.Pagination {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+
+	limits := PageLimitsFor(c)
+	return models.Pagination{
+		Total:        total,
+		Page:         page,
+		Limit:        limit,
+		TotalPages:   totalPages,
+		HasNext:      page < totalPages,
+		HasPrev:      page > 1,
+		DefaultLimit: limits.Default,
+		MaxLimit:     limits.Max,
+	}
+}
@@ -1,11 +1,29 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"unicode"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// PasswordPolicy описывает требования к сложности пароля
+type PasswordPolicy struct {
+	MinLength         int
+	RequireMixedChars bool // требовать строчные и заглавные буквы и цифры
+}
+
+// PasswordRules — действующая политика паролей, задается при старте приложения
+var PasswordRules = PasswordPolicy{
+	MinLength: 6,
+}
+
+// BcryptCost — стоимость хеширования паролей, задается при старте приложения
+var BcryptCost = bcrypt.DefaultCost
+
 func HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
 	if err != nil {
 		return "", err
 	}
@@ -15,4 +33,46 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
-}
\ No newline at end of file
+}
+
+// NeedsRehash сообщает, что хеш создан с меньшей стоимостью, чем BcryptCost, и его стоит пересчитать
+func NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return false
+	}
+	return cost < BcryptCost
+}
+
+// ValidatePassword проверяет пароль по действующей политике и сообщает, какого требования не хватает
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < PasswordRules.MinLength {
+		return fmt.Errorf("Password must be at least %d characters long", PasswordRules.MinLength)
+	}
+
+	if !PasswordRules.RequireMixedChars {
+		return nil
+	}
+
+	var hasLower, hasUpper, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+
+	switch {
+	case !hasLower:
+		return errors.New("Password must contain a lowercase letter")
+	case !hasUpper:
+		return errors.New("Password must contain an uppercase letter")
+	case !hasDigit:
+		return errors.New("Password must contain a digit")
+	}
+	return nil
+}
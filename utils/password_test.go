@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNeedsRehash(t *testing.T) {
+	hash := func(cost int) string {
+		hashed, err := bcrypt.GenerateFromPassword([]byte("secret123"), cost)
+		if err != nil {
+			t.Fatalf("hash password: %v", err)
+		}
+		return string(hashed)
+	}
+
+	tests := []struct {
+		name   string
+		hashed string
+		want   bool
+	}{
+		{name: "lower cost", hashed: hash(bcrypt.MinCost), want: true},
+		{name: "current cost", hashed: hash(BcryptCost), want: false},
+		{name: "not a bcrypt hash", hashed: "-", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NeedsRehash(tt.hashed); got != tt.want {
+				t.Fatalf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
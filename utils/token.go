@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractBearerToken достает токен из заголовка Authorization.
+// Поддерживается как формат "Bearer <token>" (схема без учета регистра), так и голый токен.
+func ExtractBearerToken(c *gin.Context) (string, error) {
+	header := strings.TrimSpace(c.GetHeader("Authorization"))
+	if header == "" {
+		return "", errors.New("missing authorization header")
+	}
+
+	parts := strings.Fields(header)
+	switch {
+	case len(parts) == 1 && !strings.EqualFold(parts[0], "bearer"):
+		return parts[0], nil
+	case len(parts) == 2 && strings.EqualFold(parts[0], "bearer"):
+		return parts[1], nil
+	default:
+		return "", errors.New("malformed authorization header")
+	}
+}
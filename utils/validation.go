@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NormalizeEmail приводит адрес к виду, в котором он хранится в базе
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeUsername приводит имя пользователя к виду, в котором оно хранится и сравнивается
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// IsValidEmail проверяет, что строка является корректным адресом без отображаемого имени
+func IsValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return false
+	}
+	return addr.Address == email && strings.Contains(email[strings.LastIndex(email, "@"):], ".")
+}
+
+// ValidateImageURLs проверяет, что ссылок не больше max и каждая является абсолютным http/https URL
+func ValidateImageURLs(urls []string, max int) error {
+	if len(urls) > max {
+		return fmt.Errorf("Field 'image_urls' must contain at most %d items", max)
+	}
+
+	for i, raw := range urls {
+		parsed, err := url.Parse(raw)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("Field 'image_urls[%d]' must be an http or https URL", i)
+		}
+	}
+	return nil
+}
+
+// NormalizeReviewText обрезает пробелы по краям текста отзыва и проверяет его длину в символах.
+// Пустой текст допускается только вместе с оценкой
+func NormalizeReviewText(text string, rating int, maxLength int) (string, error) {
+	text, err := SanitizeText("review_text", text)
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" && rating == 0 {
+		return "", fmt.Errorf("Field 'review_text' must not be empty when 'rating' is not set")
+	}
+	if utf8.RuneCountInString(text) > maxLength {
+		return "", fmt.Errorf("Field 'review_text' must be at most %d characters", maxLength)
+	}
+	return text, nil
+}
+
+// SanitizeText готовит текст к сохранению в базе: удаляет управляющие символы, кроме табуляции и переводов строки.
+// NUL и некорректный UTF-8 Postgres не принимает, поэтому такой текст отклоняется с ошибкой
+func SanitizeText(field, text string) (string, error) {
+	if strings.ContainsRune(text, 0) {
+		return "", fmt.Errorf("Field '%s' must not contain NUL bytes", field)
+	}
+	if !utf8.ValidString(text) {
+		return "", fmt.Errorf("Field '%s' must be valid UTF-8", field)
+	}
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, text), nil
+}